@@ -7,8 +7,22 @@ type key int
 const (
 	// parameterKey is a request context key.
 	parameterKey key = iota
+	// matchKey is a request context key.
+	matchKey
+	// mediaTypeKey is a request context key.
+	mediaTypeKey
+	// versionKey is a request context key.
+	versionKey
 )
 
+// routeMatch represents the Route record a request matched.
+type routeMatch struct {
+	pattern string
+	name    string
+	method  string
+	mounted bool
+}
+
 // GetParam retrieves from context a value corresponding to a given key.
 func GetParam(ctx context.Context, key string) string {
 	params, _ := ctx.Value(parameterKey).([]*parameter)
@@ -21,3 +35,61 @@ func GetParam(ctx context.Context, key string) string {
 
 	return ""
 }
+
+// MatchedRoute retrieves from context the path pattern of the Route record the request matched, e.g. /users/:id.
+// The pattern of a Route record in a Router mounted on another includes the pattern of the mount point.
+func MatchedRoute(ctx context.Context) string {
+	match, _ := ctx.Value(matchKey).(*routeMatch)
+	if match == nil {
+		return ""
+	}
+
+	return match.pattern
+}
+
+// MatchedRouteName retrieves from context the name of the Route record the request matched, if named.
+func MatchedRouteName(ctx context.Context) string {
+	match, _ := ctx.Value(matchKey).(*routeMatch)
+	if match == nil {
+		return ""
+	}
+
+	return match.name
+}
+
+// MatchedMethod retrieves from context the HTTP method of the Route record the request matched.
+// This differs from the request method if, for instance, a HEAD request was dispatched to a GET handler.
+func MatchedMethod(ctx context.Context) string {
+	match, _ := ctx.Value(matchKey).(*routeMatch)
+	if match == nil {
+		return ""
+	}
+
+	return match.method
+}
+
+// NegotiatedMediaType retrieves from context the media type negotiated for the response, if the Route record the
+// request matched declares the media types it produces. See RouteBuilder.Produces.
+func NegotiatedMediaType(ctx context.Context) string {
+	mediaType, _ := ctx.Value(mediaTypeKey).(string)
+
+	return mediaType
+}
+
+// withRouteMatch returns a copy of the given context holding the Route record matched via the given HTTP method.
+// If the context already holds a mount point matched by a parent Router, the mount point's pattern prefixes the
+// Route record's.
+func withRouteMatch(ctx context.Context, route *Route, method string, mounted bool) context.Context {
+	match := &routeMatch{
+		pattern: route.path,
+		name:    route.name,
+		method:  method,
+		mounted: mounted,
+	}
+
+	if parent, _ := ctx.Value(matchKey).(*routeMatch); parent != nil && parent.mounted {
+		match.pattern = joinPath(parent.pattern, route.path)
+	}
+
+	return context.WithValue(ctx, matchKey, match)
+}
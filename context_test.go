@@ -2,6 +2,9 @@ package turnpike
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -51,3 +54,56 @@ func TestContext(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchedRoute(t *testing.T) {
+	type testCase struct {
+		name    string
+		path    string
+		method  string
+		pattern string
+		route   string
+		matched string
+	}
+
+	// Record the match in a header, such that it survives HEAD requests.
+	record := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Match", fmt.Sprintf("%s|%s|%s", MatchedRoute(r.Context()), MatchedRouteName(r.Context()), MatchedMethod(r.Context())))
+	}
+
+	billing := NewRouter()
+	billing.WithMethods(http.MethodGet).Handler("/invoices/:id", http.HandlerFunc(record)).Name("invoice.show").MustRegister()
+
+	r := NewRouter()
+	r.HandleHEAD = true
+	r.WithMethods(http.MethodGet, http.MethodPost).Handler("/users/:id[^\\d+$]", http.HandlerFunc(record)).Name("user.show").MustRegister()
+	r.Mount("/tenants/:tenant/billing", billing)
+	r.Mount("/echo", http.HandlerFunc(record))
+
+	tests := []testCase{
+		{name: "Route", path: "/users/12", method: http.MethodPost, pattern: "/users/:id[^\\d+$]", route: "user.show", matched: http.MethodPost},
+		{name: "ImplicitHEAD", path: "/users/12", method: http.MethodHead, pattern: "/users/:id[^\\d+$]", route: "user.show", matched: http.MethodGet},
+		{name: "MountedRouter", path: "/tenants/acme/billing/invoices/1", method: http.MethodGet, pattern: "/tenants/:tenant/billing/invoices/:id", route: "invoice.show", matched: http.MethodGet},
+		{name: "MountedHandler", path: "/echo/a/b", method: http.MethodPut, pattern: "/echo", matched: http.MethodPut},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.path, nil)
+			rec := httptest.NewRecorder()
+
+			r.ServeHTTP(rec, req)
+
+			expected := fmt.Sprintf("%s|%s|%s", test.pattern, test.route, test.matched)
+			if actual := rec.Header().Get("X-Match"); actual != expected {
+				t.Errorf("expected %s but got %s", expected, actual)
+			}
+		})
+	}
+
+	t.Run("NoMatch", func(t *testing.T) {
+		ctx := context.Background()
+		if MatchedRoute(ctx) != "" || MatchedRouteName(ctx) != "" || MatchedMethod(ctx) != "" {
+			t.Error("expected empty values for a context without a match")
+		}
+	})
+}
@@ -1,8 +1,92 @@
 package turnpike
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 var (
-	ErrNotFound         = errors.New("no matching route record found")
-	ErrMethodNotAllowed = errors.New("method not allowed")
+	ErrNotFound           = errors.New("no matching route record found")
+	ErrMethodNotAllowed   = errors.New("method not allowed")
+	ErrNoMethods          = errors.New("no HTTP methods specified")
+	ErrNoPath             = errors.New("no path specified")
+	ErrNoHandler          = errors.New("no handler specified")
+	ErrInvalidPattern     = errors.New("invalid path pattern")
+	ErrDuplicateRoute     = errors.New("duplicate route record")
+	ErrAmbiguousRoute     = errors.New("ambiguous route record")
+	ErrFileHandlerMethods = errors.New("file route handlers support only the GET method")
+	ErrDuplicateName      = errors.New("duplicate route name")
+	ErrUnknownRoute       = errors.New("no route record with the given name")
+	ErrMissingParameter   = errors.New("missing path parameter")
+	ErrInvalidParameter   = errors.New("invalid path parameter")
+	ErrNoParamParser      = errors.New("no parameter parser registered for type")
+	ErrInvalidMatcher     = errors.New("invalid request matcher")
+	ErrNoMatch            = errors.New("no route record matches the request")
+	ErrNotAcceptable      = errors.New("no acceptable representation")
 )
+
+// RouteError represents a failure to register a Route record.
+type RouteError struct {
+	Methods []string
+	Path    string
+	Err     error
+}
+
+// newRouteError constructs and returns a pointer to a new RouteError for the given Route record.
+func newRouteError(route *Route, err error) *RouteError {
+	return &RouteError{
+		Methods: route.methods,
+		Path:    route.path,
+		Err:     err,
+	}
+}
+
+// Error implements the error interface.
+func (e *RouteError) Error() string {
+	if len(e.Methods) > 0 {
+		return fmt.Sprintf("cannot register route %s %s: %v", strings.Join(e.Methods, ","), e.Path, e.Err)
+	}
+
+	return fmt.Sprintf("cannot register route %s: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *RouteError) Unwrap() error {
+	return e.Err
+}
+
+// ConflictError represents a conflict between a Route record and a registered one.
+// Its underlying error is either ErrDuplicateRoute or ErrAmbiguousRoute.
+type ConflictError struct {
+	// Method holds the conflicting HTTP method, if the conflict concerns a single method.
+	Method string
+	Path   string
+	// Existing holds the path pattern of the registered Route record.
+	Existing string
+	Err      error
+}
+
+// newConflictError constructs and returns a pointer to a new ConflictError.
+func newConflictError(method string, path string, existing string, err error) *ConflictError {
+	return &ConflictError{
+		Method:   method,
+		Path:     path,
+		Existing: existing,
+		Err:      err,
+	}
+}
+
+// Error implements the error interface.
+func (e *ConflictError) Error() string {
+	if e.Method != "" {
+		return fmt.Sprintf("%v: %s %s conflicts with %s", e.Err, e.Method, e.Path, e.Existing)
+	}
+
+	return fmt.Sprintf("%v: %s conflicts with %s", e.Err, e.Path, e.Existing)
+}
+
+// Unwrap returns the underlying error.
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
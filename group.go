@@ -0,0 +1,102 @@
+package turnpike
+
+import (
+	"net/http"
+)
+
+// Group represents a set of Route records that share a path prefix, middlewares and default HTTP methods.
+type Group struct {
+	router      *Router
+	prefix      string
+	middlewares middlewares
+	methods     []string
+	// host holds the host the Group's Route records are served for, if any.
+	host *host
+	// version holds the API version the Group's Route records are registered against, if any.
+	version *Version
+}
+
+// newGroup constructs and returns a pointer to a new Group. The Group inherits the prefix, middlewares and
+// default HTTP methods of its parent, if extant.
+func newGroup(r *Router, parent *Group, prefix string) *Group {
+	g := &Group{
+		router: r,
+		prefix: joinPath("", prefix),
+	}
+
+	if parent != nil {
+		g.prefix = joinPath(parent.prefix, prefix)
+		g.middlewares = newMiddlewares(parent.middlewares)
+		g.methods = append([]string(nil), parent.methods...)
+		g.host = parent.host
+		g.version = parent.version
+	}
+
+	return g
+}
+
+// Group creates a Group of Route records prefixed with `prefix` and passes it to `fn`.
+func (r *Router) Group(prefix string, fn func(g *Group)) {
+	fn(newGroup(r, nil, prefix))
+}
+
+// Group creates a nested Group prefixed with `prefix` and passes it to `fn`. The nested Group inherits
+// the host, API version, middlewares and default HTTP methods the Group holds at the time of this invocation.
+func (g *Group) Group(prefix string, fn func(g *Group)) {
+	fn(newGroup(g.router, g, prefix))
+}
+
+// Use adds middlewares to the Group. Group middlewares run after Router middlewares and before
+// the middlewares of an individual Route record.
+func (g *Group) Use(mws ...middleware) *Group {
+	g.middlewares = append(g.middlewares, newMiddlewares(mws)...)
+
+	return g
+}
+
+// DefaultMethods appends HTTP methods to the Group's default methods. Route records registered without
+// HTTP methods of their own use the Group's default methods.
+func (g *Group) DefaultMethods(methods ...string) *Group {
+	g.methods = append(g.methods, methods...)
+
+	return g
+}
+
+// Route begins a new Route record bound to the Group.
+func (g *Group) Route() *RouteBuilder {
+	rb := newRouteBuilder(g.router)
+	rb.group = g
+
+	return rb
+}
+
+// WithMethods begins a new Route record in the Group and appends user-specified HTTP methods to it.
+func (g *Group) WithMethods(methods ...string) *RouteBuilder {
+	return g.Route().WithMethods(methods...)
+}
+
+// Handler begins a new Route record in the Group and adds a path and handler to it.
+func (g *Group) Handler(path string, handler http.Handler) *RouteBuilder {
+	return g.Route().Handler(path, handler)
+}
+
+// FileHandler begins a new Route record in the Group and registers its handler as a file server.
+// See RouteBuilder.FileHandler.
+func (g *Group) FileHandler(path string, root http.FileSystem) *RouteBuilder {
+	return g.Route().FileHandler(path, root)
+}
+
+// Mount mounts a handler at the path qualified by `prefix` within the Group. The Group's middlewares wrap the
+// mounted handler. See Router.Mount.
+func (g *Group) Mount(prefix string, handler http.Handler) error {
+	return g.router.mount(g.hostPattern(), joinPath(g.prefix, prefix), handler, newMiddlewares(g.middlewares))
+}
+
+// hostPattern returns the pattern of the host the Group's Route records are served for, if any.
+func (g *Group) hostPattern() string {
+	if g.host == nil {
+		return ""
+	}
+
+	return g.host.pattern
+}
@@ -0,0 +1,82 @@
+package turnpike
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	r := NewRouter()
+	r.UseGlobal(first)
+
+	r.Group("/api", func(g *Group) {
+		g.Use(second).DefaultMethods(http.MethodGet)
+
+		g.Handler("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "api")
+		})).Register()
+
+		g.Group("/v1", func(g *Group) {
+			g.Handler("/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, "user %s", GetParam(r.Context(), "id"))
+			})).Use(third).Register()
+
+			g.WithMethods(http.MethodPost).Handler("/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, "created")
+			})).Register()
+		})
+	})
+
+	tests := []testCase{
+		{
+			name:   "GroupRoot",
+			path:   "/api",
+			method: http.MethodGet,
+			code:   http.StatusOK,
+			body:   "first: before\nsecond: before\napisecond: after\nfirst: after\n",
+		},
+		{
+			name:   "NestedGroupMiddlewareOrder",
+			path:   "/api/v1/users/12",
+			method: http.MethodGet,
+			code:   http.StatusOK,
+			body:   "first: before\nsecond: before\nthird: before\nuser 12third: after\nsecond: after\nfirst: after\n",
+		},
+		{
+			name:   "NestedGroupExplicitMethods",
+			path:   "/api/v1/users",
+			method: http.MethodPost,
+			code:   http.StatusOK,
+			body:   "first: before\nsecond: before\ncreatedsecond: after\nfirst: after\n",
+		},
+	}
+
+	runHTTPTests(t, r, tests)
+}
+
+func TestGroupRouting(t *testing.T) {
+	r := NewRouter()
+
+	r.Group("/api/", func(g *Group) {
+		g.DefaultMethods(http.MethodGet, http.MethodPut)
+
+		g.Handler("/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "users")
+		})).Register()
+
+		g.WithMethods(http.MethodPost).Handler("/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "user %s", GetParam(r.Context(), "id"))
+		})).Register()
+	})
+
+	tests := []testCase{
+		{name: "DefaultMethods", path: "/api/users", method: http.MethodGet, code: http.StatusOK, body: "users"},
+		{name: "DefaultMethodsAlt", path: "/api/users", method: http.MethodPut, code: http.StatusOK, body: "users"},
+		{name: "ExplicitMethods", path: "/api/users/1", method: http.MethodPost, code: http.StatusOK, body: "user 1"},
+		{name: "ExplicitMethodsOverrideDefaults", path: "/api/users/1", method: http.MethodGet, code: http.StatusMethodNotAllowed},
+		{name: "UnprefixedPath", path: "/users", method: http.MethodGet, code: http.StatusNotFound},
+	}
+
+	runHTTPTests(t, r, tests)
+}
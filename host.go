@@ -0,0 +1,188 @@
+package turnpike
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+const (
+	HostDelimiter = "."
+	HostWildcard  = "*"
+)
+
+// host represents a host pattern and the trie of the Route records registered for it.
+// A host pattern consists of HostDelimiter-delimited labels, each of which is either matched verbatim and
+// regardless of case, a HostWildcard that matches any single label, or a parameter that captures a label,
+// e.g. api.example.com, *.example.com, :tenant[^[a-z]+$].example.com
+// Parameter patterns may not contain a HostDelimiter.
+type host struct {
+	pattern string
+	labels  []string
+	// regexes holds the compiled pattern of each regex parameter label, indexed as labels.
+	regexes []*regexp.Regexp
+	// exact indicates the pattern consists solely of labels matched verbatim.
+	exact bool
+	trie  *trie
+	// err holds the error the pattern failed validation with, if any. Route records registered for the host
+	// fail registration with it.
+	err error
+}
+
+// newHost constructs and returns a pointer to a new host for the given host pattern.
+func newHost(pattern string) *host {
+	h := &host{
+		pattern: pattern,
+		labels:  strings.Split(strings.TrimSuffix(pattern, HostDelimiter), HostDelimiter),
+		exact:   true,
+		trie:    newTrie(),
+	}
+
+	h.regexes = make([]*regexp.Regexp, len(h.labels))
+
+	for i, label := range h.labels {
+		switch {
+		case label == "":
+			h.err = fmt.Errorf("%w: empty label in host %s", ErrInvalidPattern, pattern)
+
+		case label == HostWildcard:
+			h.exact = false
+
+		case isParameter(label):
+			h.exact = false
+
+			if err := validateParameter(label); err != nil {
+				h.err = fmt.Errorf("%w: host %s", err, pattern)
+				break
+			}
+
+			if deriveNodeKind(label) != kindRegex {
+				break
+			}
+
+			regex, err := rc.get(deriveLabelPattern(label))
+			if err != nil {
+				h.err = fmt.Errorf("%w: host label %s: %v", ErrInvalidPattern, label, err)
+				break
+			}
+
+			h.regexes[i] = regex
+
+		default:
+			h.labels[i] = strings.ToLower(label)
+		}
+	}
+
+	return h
+}
+
+// match matches the given lower-cased hostname against the host pattern, returning the parameters it captures.
+func (h *host) match(hostname string) ([]*parameter, bool) {
+	labels := strings.Split(hostname, HostDelimiter)
+	if len(labels) != len(h.labels) {
+		return nil, false
+	}
+
+	var params []*parameter
+
+	for i, label := range h.labels {
+		switch {
+		case labels[i] == "":
+			return nil, false
+
+		case label == HostWildcard:
+
+		case isParameter(label):
+			if h.regexes[i] != nil && !h.regexes[i].MatchString(labels[i]) {
+				return nil, false
+			}
+
+			params = withParameter(params, deriveParameterKey(label), labels[i])
+
+		case label != labels[i]:
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+// Host creates a Group of Route records served only for requests whose host matches `pattern`, and passes it to `fn`.
+// A pattern consists of dot-delimited labels: a label is matched verbatim and regardless of case, a * label
+// matches any single label, and a parameter label, e.g. :tenant or :tenant[^[a-z]+$], captures a label as
+// a parameter available via GetParam and ParamsFromContext, ahead of any path parameters.
+// e.g. api.example.com, *.example.com, :tenant.example.com
+//
+// Each host pattern has a trie of its own. Requests are matched against exact host patterns first, then against
+// the others in order of registration; requests whose host matches no pattern are routed among the Route records
+// registered without a host. Any port is disregarded.
+func (r *Router) Host(pattern string, fn func(g *Group)) {
+	r.mu.Lock()
+	h := r.host(pattern)
+	if h == nil {
+		h = newHost(pattern)
+		r.hosts = append(r.hosts, h)
+	}
+	r.mu.Unlock()
+
+	g := newGroup(r, nil, "")
+	g.host = h
+
+	fn(g)
+}
+
+// host returns the Router's host with the given pattern, if extant.
+func (r *Router) host(pattern string) *host {
+	for _, h := range r.hosts {
+		if h.pattern == pattern {
+			return h
+		}
+	}
+
+	return nil
+}
+
+// trieFor returns the trie of the Router's host with the given pattern, or the Router's default trie if the
+// pattern is empty.
+func (r *Router) trieFor(pattern string) (*trie, error) {
+	if pattern == "" {
+		return r.trie, nil
+	}
+
+	h := r.host(pattern)
+	if h == nil {
+		return nil, fmt.Errorf("%w: unknown host %s", ErrInvalidPattern, pattern)
+	}
+
+	return h.trie, h.err
+}
+
+// matchHost returns the trie of the Router's host matching the given request host, and the parameters the host
+// captures. If no host matches, matchHost returns the Router's default trie.
+func (r *Router) matchHost(requestHost string) (*trie, []*parameter) {
+	if len(r.hosts) == 0 {
+		return r.trie, nil
+	}
+
+	hostname := requestHost
+	if name, _, err := net.SplitHostPort(requestHost); err == nil {
+		hostname = name
+	}
+
+	hostname = strings.ToLower(strings.TrimSuffix(hostname, HostDelimiter))
+
+	for _, exact := range []bool{true, false} {
+		for _, h := range r.hosts {
+			if h.exact != exact || h.err != nil {
+				continue
+			}
+
+			if params, ok := h.match(hostname); ok {
+				return h.trie, params
+			}
+		}
+	}
+
+	return r.trie, nil
+}
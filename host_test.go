@@ -0,0 +1,168 @@
+package turnpike
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestHostMatch(t *testing.T) {
+	type testCase struct {
+		name     string
+		pattern  string
+		hostname string
+		params   []*parameter
+		ok       bool
+	}
+
+	tests := []testCase{
+		{name: "Exact", pattern: "api.example.com", hostname: "api.example.com", ok: true},
+		{name: "ExactCase", pattern: "API.example.com", hostname: "api.example.com", ok: true},
+		{name: "ExactMismatch", pattern: "api.example.com", hostname: "admin.example.com"},
+		{name: "LabelCount", pattern: "example.com", hostname: "api.example.com"},
+		{name: "Wildcard", pattern: "*.example.com", hostname: "www.example.com", ok: true},
+		{name: "WildcardSingleLabel", pattern: "*.example.com", hostname: "a.b.example.com"},
+		{
+			name:     "Parameter",
+			pattern:  ":tenant.example.com",
+			hostname: "acme.example.com",
+			params:   []*parameter{{key: "tenant", value: "acme"}},
+			ok:       true,
+		},
+		{
+			name:     "RegexParameter",
+			pattern:  ":tenant[^[a-z]+$].:region.example.com",
+			hostname: "acme.eu.example.com",
+			params:   []*parameter{{key: "tenant", value: "acme"}, {key: "region", value: "eu"}},
+			ok:       true,
+		},
+		{name: "RegexParameterMismatch", pattern: ":tenant[^[a-z]+$].example.com", hostname: "42.example.com"},
+		{name: "EmptyLabel", pattern: ":tenant.example.com", hostname: ".example.com"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params, ok := newHost(test.pattern).match(test.hostname)
+			if ok != test.ok {
+				t.Fatalf("expected match %v but got %v", test.ok, ok)
+			}
+
+			if !reflect.DeepEqual(params, test.params) {
+				t.Errorf("expected parameters %v but got %v", test.params, params)
+			}
+		})
+	}
+}
+
+func TestHostRouting(t *testing.T) {
+	respond := func(s string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, s)
+			for _, param := range ParamsFromContext(r.Context()).All() {
+				fmt.Fprintf(w, ";%s=%s", param.Key, param.Value)
+			}
+		})
+	}
+
+	r := NewRouter()
+	r.WithMethods(http.MethodGet).Handler("/users/:id", respond("default")).MustRegister()
+
+	r.Host(":tenant.example.com", func(g *Group) {
+		g.WithMethods(http.MethodGet).Handler("/users/:id", respond("tenant")).MustRegister()
+	})
+	r.Host("*.example.com", func(g *Group) {
+		g.WithMethods(http.MethodGet).Handler("/users/:id", respond("wildcard")).MustRegister()
+	})
+	r.Host("api.example.com", func(g *Group) {
+		g.Group("/v1", func(g *Group) {
+			g.WithMethods(http.MethodGet).Handler("/users/:id", respond("api")).MustRegister()
+		})
+		g.Mount("/admin", respond("admin"))
+	})
+
+	tests := []testCase{
+		{name: "ExactPrecedence", path: "http://api.example.com/v1/users/42", method: http.MethodGet, code: http.StatusOK, body: "api;id=42"},
+		{name: "ExactPort", path: "http://API.example.com:8080/v1/users/42", method: http.MethodGet, code: http.StatusOK, body: "api;id=42"},
+		{name: "ExactMount", path: "http://api.example.com/admin/x", method: http.MethodGet, code: http.StatusOK, body: "admin"},
+		{name: "ExactNoFallback", path: "http://api.example.com/users/42", method: http.MethodGet, code: http.StatusNotFound},
+		{
+			name:   "ParameterHost",
+			path:   "http://acme.example.com/users/42",
+			method: http.MethodGet,
+			code:   http.StatusOK,
+			body:   "tenant;tenant=acme;id=42",
+		},
+		{name: "UnmatchedHost", path: "http://example.org/users/42", method: http.MethodGet, code: http.StatusOK, body: "default;id=42"},
+		{name: "DeeperUnmatchedHost", path: "http://a.b.example.com/users/42", method: http.MethodGet, code: http.StatusOK, body: "default;id=42"},
+	}
+
+	runHTTPTests(t, r, tests)
+}
+
+func TestHostRegistrationOrder(t *testing.T) {
+	r := NewRouter()
+
+	r.Host("*.example.com", func(g *Group) {
+		g.WithMethods(http.MethodGet).Handler("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "wildcard")
+		})).MustRegister()
+	})
+	r.Host(":tenant.example.com", func(g *Group) {
+		g.WithMethods(http.MethodGet).Handler("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "tenant")
+		})).MustRegister()
+	})
+
+	runHTTPTests(t, r, []testCase{
+		{name: "FirstRegistered", path: "http://acme.example.com/", method: http.MethodGet, code: http.StatusOK, body: "wildcard"},
+	})
+}
+
+func TestHostErrors(t *testing.T) {
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	r := NewRouter()
+
+	r.Host("api..example.com", func(g *Group) {
+		if err := g.WithMethods(http.MethodGet).Handler("/", testHandler).Register(); !errors.Is(err, ErrInvalidPattern) {
+			t.Errorf("expected error %v but got %v", ErrInvalidPattern, err)
+		}
+	})
+
+	r.Host(":tenant[(].example.com", func(g *Group) {
+		if err := g.Mount("/", testHandler); !errors.Is(err, ErrInvalidPattern) {
+			t.Errorf("expected error %v but got %v", ErrInvalidPattern, err)
+		}
+	})
+
+	// Route records are distinct per host.
+	r.WithMethods(http.MethodGet).Handler("/", testHandler).MustRegister()
+	r.Host("api.example.com", func(g *Group) {
+		g.WithMethods(http.MethodGet).Handler("/", testHandler).MustRegister()
+
+		if err := g.WithMethods(http.MethodGet).Handler("/", testHandler).Register(); !errors.Is(err, ErrDuplicateRoute) {
+			t.Errorf("expected error %v but got %v", ErrDuplicateRoute, err)
+		}
+	})
+}
+
+func TestHostRoutes(t *testing.T) {
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	r := NewRouter()
+	r.Host("admin.example.com", func(g *Group) {
+		g.WithMethods(http.MethodGet).Handler("/users", testHandler).MustRegister()
+	})
+	r.WithMethods(http.MethodGet).Handler("/users", testHandler).MustRegister()
+
+	expected := []RouteInfo{
+		{Pattern: "/users", Methods: []string{http.MethodGet}, Constraints: map[string]string{}},
+		{Pattern: "/users", Host: "admin.example.com", Methods: []string{http.MethodGet}, Constraints: map[string]string{}},
+	}
+
+	if actual := r.Routes(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v but got %v", expected, actual)
+	}
+}
@@ -0,0 +1,97 @@
+package turnpike
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// RequestMatcher determines whether a request qualifies for a Route record beyond its HTTP method and path.
+type RequestMatcher func(req *http.Request) bool
+
+// newHeaderMatcher returns a RequestMatcher that matches requests bearing each of the given header key-value pairs.
+// See RouteBuilder.Headers.
+func newHeaderMatcher(pairs []string) (RequestMatcher, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("%w: odd number of header key-value pairs", ErrInvalidMatcher)
+	}
+
+	return func(req *http.Request) bool {
+		for i := 0; i < len(pairs); i += 2 {
+			values := req.Header.Values(pairs[i])
+			if len(values) == 0 {
+				return false
+			}
+
+			if pairs[i+1] != "" && !containsHeaderValue(values, pairs[i+1]) {
+				return false
+			}
+		}
+
+		return true
+	}, nil
+}
+
+// containsHeaderValue determines whether any of the given header values equals `value`, disregarding case and any
+// parameters, e.g. application/json; charset=utf-8 equals application/json.
+func containsHeaderValue(values []string, value string) bool {
+	for _, v := range values {
+		if mediaType, _, err := mime.ParseMediaType(v); err == nil {
+			v = mediaType
+		}
+
+		if strings.EqualFold(strings.TrimSpace(v), value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newQueryMatcher returns a RequestMatcher that matches requests bearing each of the given query key-value pairs.
+// See RouteBuilder.Queries.
+func newQueryMatcher(pairs []string) (RequestMatcher, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("%w: odd number of query key-value pairs", ErrInvalidMatcher)
+	}
+
+	return func(req *http.Request) bool {
+		query := req.URL.Query()
+
+		for i := 0; i < len(pairs); i += 2 {
+			values, ok := query[pairs[i]]
+			if !ok {
+				return false
+			}
+
+			if pairs[i+1] != "" && !contains(values, pairs[i+1]) {
+				return false
+			}
+		}
+
+		return true
+	}, nil
+}
+
+// newSchemeMatcher returns a RequestMatcher that matches requests made via any of the given URL schemes.
+// See RouteBuilder.Schemes.
+func newSchemeMatcher(schemes []string) RequestMatcher {
+	return func(req *http.Request) bool {
+		scheme := req.URL.Scheme
+		if scheme == "" {
+			scheme = "http"
+			if req.TLS != nil {
+				scheme = "https"
+			}
+		}
+
+		for _, s := range schemes {
+			if strings.EqualFold(s, scheme) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
@@ -0,0 +1,247 @@
+package turnpike
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHeaderMatcher(t *testing.T) {
+	type testCase struct {
+		name     string
+		pairs    []string
+		header   http.Header
+		expected bool
+	}
+
+	tests := []testCase{
+		{name: "Value", pairs: []string{"X-API-Version", "2"}, header: http.Header{"X-Api-Version": {"2"}}, expected: true},
+		{name: "ValueMismatch", pairs: []string{"X-API-Version", "2"}, header: http.Header{"X-Api-Version": {"1"}}},
+		{name: "Absent", pairs: []string{"X-API-Version", "2"}, header: http.Header{}},
+		{name: "Presence", pairs: []string{"X-API-Version", ""}, header: http.Header{"X-Api-Version": {"1"}}, expected: true},
+		{
+			name:     "MediaTypeParameters",
+			pairs:    []string{"Content-Type", "application/json"},
+			header:   http.Header{"Content-Type": {"Application/JSON; charset=utf-8"}},
+			expected: true,
+		},
+		{
+			name:     "EveryPair",
+			pairs:    []string{"Content-Type", "text/csv", "X-API-Version", "2"},
+			header:   http.Header{"Content-Type": {"text/csv"}},
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			matcher, err := newHeaderMatcher(test.pairs)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header = test.header
+
+			if actual := matcher(req); actual != test.expected {
+				t.Errorf("expected %v but got %v", test.expected, actual)
+			}
+		})
+	}
+
+	if _, err := newHeaderMatcher([]string{"X-API-Version"}); !errors.Is(err, ErrInvalidMatcher) {
+		t.Errorf("expected error %v but got %v", ErrInvalidMatcher, err)
+	}
+}
+
+func TestQueryMatcher(t *testing.T) {
+	type testCase struct {
+		name     string
+		pairs    []string
+		target   string
+		expected bool
+	}
+
+	tests := []testCase{
+		{name: "Value", pairs: []string{"format", "csv"}, target: "/?format=csv", expected: true},
+		{name: "AnyValue", pairs: []string{"format", "csv"}, target: "/?format=json&format=csv", expected: true},
+		{name: "ValueMismatch", pairs: []string{"format", "csv"}, target: "/?format=json"},
+		{name: "Presence", pairs: []string{"debug", ""}, target: "/?debug", expected: true},
+		{name: "Absent", pairs: []string{"debug", ""}, target: "/"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			matcher, err := newQueryMatcher(test.pairs)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if actual := matcher(httptest.NewRequest(http.MethodGet, test.target, nil)); actual != test.expected {
+				t.Errorf("expected %v but got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSchemeMatcher(t *testing.T) {
+	matcher := newSchemeMatcher([]string{"HTTPS"})
+
+	plain := httptest.NewRequest(http.MethodGet, "/", nil)
+	secure := httptest.NewRequest(http.MethodGet, "/", nil)
+	secure.TLS = &tls.ConnectionState{}
+	absolute := httptest.NewRequest(http.MethodGet, "https://example.com/", nil)
+
+	if matcher(plain) || !matcher(secure) || !matcher(absolute) {
+		t.Errorf("expected only https requests to match")
+	}
+}
+
+func TestRequestMatchers(t *testing.T) {
+	type testCase struct {
+		name   string
+		target string
+		header http.Header
+		code   int
+		body   string
+	}
+
+	respond := func(s string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, s)
+		})
+	}
+
+	respondParam := func(s string, key string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "%s:%s", s, GetParam(r.Context(), key))
+		})
+	}
+
+	r := NewRouter()
+	r.WithMethods(http.MethodPost).Handler("/reports", respond("json")).Headers("Content-Type", "application/json").MustRegister()
+	r.WithMethods(http.MethodPost).Handler("/reports", respond("fallback")).MustRegister()
+	r.WithMethods(http.MethodPost).Handler("/reports", respond("v2")).Headers("X-API-Version", "2").MustRegister()
+	r.WithMethods(http.MethodPost).Handler("/reports", respond("csv")).Queries("format", "csv").MustRegister()
+	r.WithMethods(http.MethodPost).Handler("/imports", respond("custom")).MatcherFunc(func(req *http.Request) bool {
+		return req.URL.Query().Has("dry")
+	}).MustRegister()
+	// Route records with matchers are never ambiguous, whatever their parameter keys.
+	r.WithMethods(http.MethodPost).Handler("/items/:x", respondParam("v1", "x")).Headers("X-V", "1").MustRegister()
+	r.WithMethods(http.MethodPost).Handler("/items/:y", respondParam("v2", "y")).Headers("X-V", "2").MustRegister()
+	r.WithMethods(http.MethodPost).Handler("/items/:z?", respondParam("v3", "z")).Headers("X-V", "3").MustRegister()
+
+	tests := []testCase{
+		{name: "FirstMatcher", target: "/reports", header: http.Header{"Content-Type": {"application/json"}, "X-Api-Version": {"2"}}, code: http.StatusOK, body: "json"},
+		{name: "LaterMatcher", target: "/reports", header: http.Header{"X-Api-Version": {"2"}}, code: http.StatusOK, body: "v2"},
+		{name: "RegisteredAfterFallback", target: "/reports?format=csv", code: http.StatusOK, body: "csv"},
+		{name: "Fallback", target: "/reports", code: http.StatusOK, body: "fallback"},
+		{name: "CustomMatcher", target: "/imports?dry", code: http.StatusOK, body: "custom"},
+		{name: "NoMatch", target: "/imports", code: http.StatusBadRequest, body: ErrNoMatch.Error() + "\n"},
+		{name: "RenamedParameter", target: "/items/42", header: http.Header{"X-V": {"1"}}, code: http.StatusOK, body: "v1:42"},
+		{name: "LaterRenamedParameter", target: "/items/42", header: http.Header{"X-V": {"2"}}, code: http.StatusOK, body: "v2:42"},
+		{name: "OverlappingOptionalParameter", target: "/items", header: http.Header{"X-V": {"3"}}, code: http.StatusOK, body: "v3:"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, test.target, nil)
+			if test.header != nil {
+				req.Header = test.header
+			}
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != test.code {
+				t.Errorf("expected code %d but got %d", test.code, rec.Code)
+			}
+
+			if body, _ := ioutil.ReadAll(rec.Body); test.body != "" && string(body) != test.body {
+				t.Errorf("expected body %s but got %s", test.body, body)
+			}
+		})
+	}
+}
+
+func TestNoMatchHandler(t *testing.T) {
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	r := NewRouter()
+	r.NoMatchHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+	})
+	r.WithMethods(http.MethodPost).Handler("/reports", testHandler).Headers("Content-Type", "application/json").MustRegister()
+
+	runHTTPTests(t, r, []testCase{
+		{name: "CustomNoMatchHandler", path: "/reports", method: http.MethodPost, code: http.StatusUnsupportedMediaType},
+		{name: "MethodNotAllowed", path: "/reports", method: http.MethodGet, code: http.StatusMethodNotAllowed},
+	})
+}
+
+func TestRegisterMatchers(t *testing.T) {
+	type testCase struct {
+		name     string
+		build    func(r *Router) *RouteBuilder
+		expected error
+	}
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	r := NewRouter()
+	r.WithMethods(http.MethodGet).Handler("/a", testHandler).MustRegister()
+	r.WithMethods(http.MethodGet).Handler("/b", testHandler).Schemes("https").MustRegister()
+
+	tests := []testCase{
+		{
+			name: "ConditionalAlongsideFallback",
+			build: func(r *Router) *RouteBuilder {
+				return r.WithMethods(http.MethodGet).Handler("/a", testHandler).Headers("X-API-Version", "2")
+			},
+		},
+		{
+			name: "FallbackAlongsideConditional",
+			build: func(r *Router) *RouteBuilder {
+				return r.WithMethods(http.MethodGet).Handler("/b", testHandler)
+			},
+		},
+		{
+			name: "DuplicateFallback",
+			build: func(r *Router) *RouteBuilder {
+				return r.WithMethods(http.MethodGet).Handler("/b", testHandler)
+			},
+			expected: ErrDuplicateRoute,
+		},
+		{
+			name: "OddHeaderPairs",
+			build: func(r *Router) *RouteBuilder {
+				return r.WithMethods(http.MethodGet).Handler("/c", testHandler).Headers("X-API-Version")
+			},
+			expected: ErrInvalidMatcher,
+		},
+		{
+			name: "NilMatcher",
+			build: func(r *Router) *RouteBuilder {
+				return r.WithMethods(http.MethodGet).Handler("/c", testHandler).MatcherFunc(nil)
+			},
+			expected: ErrInvalidMatcher,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.build(r).Register(); !errors.Is(err, test.expected) {
+				t.Errorf("expected error %v but got %v", test.expected, err)
+			}
+		})
+	}
+
+	// Route records with matchers precede the Route record without.
+	if routes := r.Routes(); len(routes) != 4 || routes[0].Matchers != 1 || routes[1].Matchers != 0 {
+		t.Errorf("unexpected routes %v", routes)
+	}
+}
@@ -3,6 +3,7 @@ package turnpike
 import (
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"reflect"
 	"testing"
@@ -76,6 +77,107 @@ func TestMiddlewareInvocation(t *testing.T) {
 	runHTTPTests(t, r, tests)
 }
 
+func TestRouteMiddlewareChain(t *testing.T) {
+	r := NewRouter()
+
+	// Use begins a Route record, as it did before UseGlobal was introduced.
+	r.Use(first).WithMethods(http.MethodGet).Handler("/admin", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "admin")
+	})).Register()
+
+	r.WithMethods(http.MethodGet).Handler("/public", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "public")
+	})).Register()
+
+	tests := []testCase{
+		{
+			name:   "RouteMiddleware",
+			path:   "/admin",
+			method: http.MethodGet,
+			code:   http.StatusOK,
+			body:   "first: before\nadminfirst: after\n",
+		},
+		{
+			name:   "OtherRoute",
+			path:   "/public",
+			method: http.MethodGet,
+			code:   http.StatusOK,
+			body:   "public",
+		},
+	}
+
+	runHTTPTests(t, r, tests)
+}
+
+func TestRouterMiddlewareInvocation(t *testing.T) {
+	r := NewRouter()
+	r.UseGlobal(first)
+
+	r.WithMethods(http.MethodGet).Handler("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "/")
+	})).Use(second).Register()
+
+	r.WithMethods(http.MethodGet).Handler("/foo", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "foo")
+	})).Register()
+
+	// Router middlewares added after registration still apply.
+	r.UseGlobal(third)
+
+	tests := []testCase{
+		{
+			name:   "RouterAndRouteMiddleware",
+			path:   "/",
+			method: http.MethodGet,
+			code:   http.StatusOK,
+			body:   "first: before\nthird: before\nsecond: before\n/second: after\nthird: after\nfirst: after\n",
+		},
+		{
+			name:   "RouterMiddlewareOnly",
+			path:   "/foo",
+			method: http.MethodGet,
+			code:   http.StatusOK,
+			body:   "first: before\nthird: before\nfoothird: after\nfirst: after\n",
+		},
+	}
+
+	runHTTPTests(t, r, tests)
+}
+
+func TestRouterMiddlewareErrorHandlers(t *testing.T) {
+	r := NewRouter()
+	r.UseGlobal(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Middleware", "invoked")
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	r.WithMethods(http.MethodGet).Handler("/foo", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).Register()
+
+	tests := []testCase{
+		{name: "NotFound", path: "/bar", method: http.MethodGet, code: http.StatusNotFound},
+		{name: "MethodNotAllowed", path: "/foo", method: http.MethodPost, code: http.StatusMethodNotAllowed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.path, nil)
+			rec := httptest.NewRecorder()
+
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != test.code {
+				t.Errorf("expected code %d but got %d\n", test.code, rec.Code)
+			}
+
+			if rec.Header().Get("X-Middleware") != "invoked" {
+				t.Error("expected router middleware to be invoked")
+			}
+		})
+	}
+}
+
 func first(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(w, "first: before\n")
@@ -0,0 +1,164 @@
+package turnpike
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// mediaRange represents a media range of an Accept header, e.g. text/*;q=0.5.
+type mediaRange struct {
+	mediaType string
+	subtype   string
+	params    map[string]string
+	quality   float64
+}
+
+// parseAccept parses the media ranges of the given Accept header values per RFC 9110, section 12.5.1.
+// Invalid media ranges are disregarded.
+func parseAccept(values []string) []mediaRange {
+	var ranges []mediaRange
+
+	for _, value := range values {
+		for _, s := range strings.Split(value, ",") {
+			s = strings.TrimSpace(s)
+			if s == "" {
+				continue
+			}
+
+			mediaType, params, err := mime.ParseMediaType(s)
+			if err != nil {
+				continue
+			}
+
+			// Some clients abbreviate */* as *.
+			if mediaType == "*" {
+				mediaType = "*/*"
+			}
+
+			typ, subtype, ok := strings.Cut(mediaType, "/")
+			if !ok || typ == "*" && subtype != "*" {
+				continue
+			}
+
+			r := mediaRange{mediaType: typ, subtype: subtype, params: params, quality: 1}
+
+			if q, ok := params["q"]; ok {
+				quality, err := strconv.ParseFloat(q, 64)
+				if err != nil || quality < 0 || quality > 1 {
+					continue
+				}
+
+				r.quality = quality
+				delete(params, "q")
+			}
+
+			ranges = append(ranges, r)
+		}
+	}
+
+	return ranges
+}
+
+// specificity ranks the media range by how specifically it qualifies media types: */* is the least specific,
+// then type/*, then type/subtype, then type/subtype with parameters, by number of parameters.
+func (r mediaRange) specificity() int {
+	switch {
+	case r.mediaType == "*":
+		return 0
+	case r.subtype == "*":
+		return 1
+	default:
+		return 2 + len(r.params)
+	}
+}
+
+// matches determines whether the media range qualifies the given media type and its parameters.
+func (r mediaRange) matches(mediaType string, params map[string]string) bool {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+
+	if r.mediaType != "*" && r.mediaType != typ {
+		return false
+	}
+
+	if r.subtype != "*" && r.subtype != subtype {
+		return false
+	}
+
+	for key, value := range r.params {
+		if !strings.EqualFold(params[key], value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// quality returns the quality the given media ranges assign the given media type: that of the most specific media
+// range qualifying it, or 0 if none does.
+func quality(ranges []mediaRange, mediaType string) float64 {
+	mediaType, params, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return 0
+	}
+
+	q, specificity := 0.0, -1
+
+	for _, r := range ranges {
+		if r.matches(mediaType, params) && r.specificity() > specificity {
+			q, specificity = r.quality, r.specificity()
+		}
+	}
+
+	return q
+}
+
+// acceptRanges returns the media ranges of the given request's Accept header. A request without an Accept header
+// accepts any media type.
+func acceptRanges(req *http.Request) []mediaRange {
+	values := req.Header.Values("Accept")
+	if len(values) == 0 {
+		values = []string{"*/*"}
+	}
+
+	return parseAccept(values)
+}
+
+// negotiate selects among the given actions the one whose produced media type the given media ranges prefer,
+// returning the action and the media type. Of media types of equal quality, the first produced by the
+// first action is selected. An action that declares no media types is selected only if no produced media type is
+// acceptable. If no action is acceptable, negotiate returns ErrNotAcceptable.
+func negotiate(actions []*action, ranges []mediaRange) (*action, string, error) {
+	var (
+		best         *action
+		bestType     string
+		bestQuality  float64
+		unrestricted *action
+	)
+
+	for _, a := range actions {
+		if len(a.produces) == 0 {
+			if unrestricted == nil {
+				unrestricted = a
+			}
+			continue
+		}
+
+		for _, mediaType := range a.produces {
+			if q := quality(ranges, mediaType); q > bestQuality {
+				best, bestType, bestQuality = a, mediaType, q
+			}
+		}
+	}
+
+	if best != nil {
+		return best, bestType, nil
+	}
+
+	if unrestricted != nil {
+		return unrestricted, "", nil
+	}
+
+	return nil, "", ErrNotAcceptable
+}
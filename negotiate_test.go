@@ -0,0 +1,228 @@
+package turnpike
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseAccept(t *testing.T) {
+	type testCase struct {
+		name     string
+		input    []string
+		expected []mediaRange
+	}
+
+	tests := []testCase{
+		{
+			name:  "QualityValues",
+			input: []string{"text/html, application/json;q=0.9, */*;q=0.1"},
+			expected: []mediaRange{
+				{mediaType: "text", subtype: "html", params: map[string]string{}, quality: 1},
+				{mediaType: "application", subtype: "json", params: map[string]string{}, quality: 0.9},
+				{mediaType: "*", subtype: "*", params: map[string]string{}, quality: 0.1},
+			},
+		},
+		{
+			name:  "MediaTypeParameters",
+			input: []string{"application/vnd.acme+json; version=2; q=0.5", "text/*"},
+			expected: []mediaRange{
+				{mediaType: "application", subtype: "vnd.acme+json", params: map[string]string{"version": "2"}, quality: 0.5},
+				{mediaType: "text", subtype: "*", params: map[string]string{}, quality: 1},
+			},
+		},
+		{
+			name:     "AbbreviatedWildcard",
+			input:    []string{"*"},
+			expected: []mediaRange{{mediaType: "*", subtype: "*", params: map[string]string{}, quality: 1}},
+		},
+		{
+			name:     "InvalidRanges",
+			input:    []string{"text/html;q=2, */json, application/json;q=x, ;, text/plain"},
+			expected: []mediaRange{{mediaType: "text", subtype: "plain", params: map[string]string{}, quality: 1}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := parseAccept(test.input); !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected %v but got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestQuality(t *testing.T) {
+	type testCase struct {
+		name      string
+		accept    string
+		mediaType string
+		expected  float64
+	}
+
+	tests := []testCase{
+		{name: "Exact", accept: "application/json", mediaType: "application/json", expected: 1},
+		{name: "MostSpecificRange", accept: "text/*;q=0.3, text/csv;q=0.7, */*;q=0.1", mediaType: "text/csv", expected: 0.7},
+		{name: "SubtypeWildcard", accept: "text/*;q=0.3, text/csv;q=0.7, */*;q=0.1", mediaType: "text/plain", expected: 0.3},
+		{name: "Wildcard", accept: "text/*;q=0.3, text/csv;q=0.7, */*;q=0.1", mediaType: "application/pdf", expected: 0.1},
+		{name: "Excluded", accept: "application/json, application/pdf;q=0", mediaType: "application/pdf", expected: 0},
+		{name: "Unqualified", accept: "application/json", mediaType: "text/csv", expected: 0},
+		{name: "Parameters", accept: "application/json;version=2", mediaType: "application/json; version=2", expected: 1},
+		{name: "ParameterMismatch", accept: "application/json;version=2", mediaType: "application/json; version=1", expected: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := quality(parseAccept([]string{test.accept}), test.mediaType); actual != test.expected {
+				t.Errorf("expected %v but got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestContentNegotiation(t *testing.T) {
+	type testCase struct {
+		name      string
+		accept    string
+		code      int
+		body      string
+		mediaType string
+	}
+
+	respond := func(s string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "%s:%s", s, NegotiatedMediaType(r.Context()))
+		})
+	}
+
+	r := NewRouter()
+	r.WithMethods(http.MethodGet).Handler("/reports/:id", respond("json")).Produces("application/json").MustRegister()
+	r.WithMethods(http.MethodGet).Handler("/reports/:id", respond("tabular")).Produces("text/csv", "text/tab-separated-values").MustRegister()
+	r.WithMethods(http.MethodGet).Handler("/reports/:id", respond("pdf")).Produces("application/pdf").MustRegister()
+	r.WithMethods(http.MethodGet).Handler("/exports/:id", respond("json")).Produces("application/json").MustRegister()
+	r.WithMethods(http.MethodGet).Handler("/exports/:id", respond("default")).MustRegister()
+
+	tests := []testCase{
+		{name: "NoAccept", code: http.StatusOK, body: "json:application/json"},
+		{name: "Exact", accept: "application/pdf", code: http.StatusOK, body: "pdf:application/pdf"},
+		{name: "QualityValues", accept: "application/json;q=0.5, text/csv", code: http.StatusOK, body: "tabular:text/csv"},
+		{name: "SecondMediaType", accept: "text/tab-separated-values", code: http.StatusOK, body: "tabular:text/tab-separated-values"},
+		{name: "TieByRegistration", accept: "text/*, application/*", code: http.StatusOK, body: "json:application/json"},
+		{name: "Excluded", accept: "*/*, application/json;q=0, text/*;q=0", code: http.StatusOK, body: "pdf:application/pdf"},
+		{name: "NotAcceptable", accept: "image/png", code: http.StatusNotAcceptable},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/reports/42", nil)
+			if test.accept != "" {
+				req.Header.Set("Accept", test.accept)
+			}
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != test.code {
+				t.Errorf("expected code %d but got %d", test.code, rec.Code)
+			}
+
+			if body, _ := ioutil.ReadAll(rec.Body); string(body) != test.body && test.body != "" {
+				t.Errorf("expected body %s but got %s", test.body, body)
+			}
+
+			if vary := rec.Header().Get("Vary"); vary != "Accept" {
+				t.Errorf("expected Vary header Accept but got %s", vary)
+			}
+		})
+	}
+
+	t.Run("Unrestricted", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/exports/42", nil)
+		req.Header.Set("Accept", "text/html")
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		if body, _ := ioutil.ReadAll(rec.Body); string(body) != "default:" {
+			t.Errorf("expected body default: but got %s", body)
+		}
+	})
+}
+
+func TestNotAcceptableHandler(t *testing.T) {
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	r := NewRouter()
+	r.NotAcceptableHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	r.WithMethods(http.MethodGet).Handler("/reports", testHandler).Produces("application/json").MustRegister()
+
+	req := httptest.NewRequest(http.MethodGet, "/reports", nil)
+	req.Header.Set("Accept", "text/csv")
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("expected code %d but got %d", http.StatusTeapot, rec.Code)
+	}
+
+	err := r.WithMethods(http.MethodGet).Handler("/reports", testHandler).Produces("application/json; =").Register()
+	if !errors.Is(err, ErrInvalidMatcher) {
+		t.Errorf("expected error %v but got %v", ErrInvalidMatcher, err)
+	}
+}
+
+func TestRegisterProduces(t *testing.T) {
+	type testCase struct {
+		name     string
+		build    func(r *Router) *RouteBuilder
+		expected error
+	}
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	r := NewRouter()
+	r.WithMethods(http.MethodGet).Handler("/reports/:id", testHandler).Produces("text/csv", "application/json").MustRegister()
+
+	tests := []testCase{
+		{
+			name: "DuplicateMediaTypes",
+			build: func(r *Router) *RouteBuilder {
+				return r.WithMethods(http.MethodGet).Handler("/reports/:id", testHandler).Produces("application/json", "Text/CSV")
+			},
+			expected: ErrDuplicateRoute,
+		},
+		{
+			name: "DistinctMediaTypes",
+			build: func(r *Router) *RouteBuilder {
+				return r.WithMethods(http.MethodGet).Handler("/reports/:id", testHandler).Produces("text/csv")
+			},
+		},
+		{
+			name: "DistinctMethod",
+			build: func(r *Router) *RouteBuilder {
+				return r.WithMethods(http.MethodPost).Handler("/reports/:id", testHandler).Produces("text/csv", "application/json")
+			},
+		},
+		{
+			name: "WithMatchers",
+			build: func(r *Router) *RouteBuilder {
+				return r.WithMethods(http.MethodGet).Handler("/reports/:id", testHandler).Produces("text/csv", "application/json").Headers("X-V", "2")
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.build(r).Register(); !errors.Is(err, test.expected) {
+				t.Errorf("expected error %v but got %v", test.expected, err)
+			}
+		})
+	}
+}
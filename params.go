@@ -0,0 +1,151 @@
+package turnpike
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// paramParsers holds the parsers Param uses, keyed by the type they parse into.
+var paramParsers = struct {
+	sync.RWMutex
+	parsers map[reflect.Type]interface{}
+}{
+	parsers: make(map[reflect.Type]interface{}),
+}
+
+func init() {
+	RegisterParamParser(func(s string) (string, error) { return s, nil })
+	RegisterParamParser(strconv.Atoi)
+	RegisterParamParser(func(s string) (int64, error) { return strconv.ParseInt(s, 10, 64) })
+	RegisterParamParser(func(s string) (uint64, error) { return strconv.ParseUint(s, 10, 64) })
+	RegisterParamParser(func(s string) (float64, error) { return strconv.ParseFloat(s, 64) })
+	RegisterParamParser(strconv.ParseBool)
+	RegisterParamParser(ParseUUID)
+}
+
+// Parameter represents a parameter captured from a request path.
+type Parameter struct {
+	Key   string
+	Value string
+}
+
+// Params represents the parameters captured from a request path, in path order. Params may be indexed directly,
+// e.g. params[0] is the first parameter in the path.
+type Params []Parameter
+
+// ParamsFromContext retrieves from context the parameters captured from the request path, in path order.
+// Parameters captured by a parent Router on which the matching Router is mounted precede its own.
+func ParamsFromContext(ctx context.Context) Params {
+	params, _ := ctx.Value(parameterKey).([]*parameter)
+
+	ret := make(Params, len(params))
+	for i, param := range params {
+		ret[i] = Parameter{
+			Key:   param.key,
+			Value: param.value,
+		}
+	}
+
+	return ret
+}
+
+// Get returns the value of the first parameter keyed by `key`, or an empty string if no such parameter exists.
+func (p Params) Get(key string) string {
+	value, _ := p.Lookup(key)
+
+	return value
+}
+
+// Lookup returns the value of the first parameter keyed by `key`, and whether such a parameter exists.
+// Lookup distinguishes a missing parameter from one captured with an empty value.
+func (p Params) Lookup(key string) (string, bool) {
+	for _, param := range p {
+		if param.Key == key {
+			return param.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// Len returns the number of parameters.
+func (p Params) Len() int {
+	return len(p)
+}
+
+// All returns a copy of the parameters, in path order.
+func (p Params) All() []Parameter {
+	return append([]Parameter(nil), p...)
+}
+
+// RegisterParamParser registers the parser Param uses to parse parameter values into type T, replacing any
+// parser registered for T. Parsers for string, int, int64, uint64, float64, bool and UUID are registered by default.
+func RegisterParamParser[T any](parse func(string) (T, error)) {
+	paramParsers.Lock()
+	defer paramParsers.Unlock()
+
+	paramParsers.parsers[reflect.TypeOf((*T)(nil)).Elem()] = parse
+}
+
+// Param retrieves from context the value of the parameter keyed by `key`, parsed into type T by the parser
+// registered for T. Param returns ErrMissingParameter if no such parameter exists, ErrInvalidParameter if the value
+// cannot be parsed, and ErrNoParamParser if no parser is registered for T.
+func Param[T any](ctx context.Context, key string) (T, error) {
+	var zero T
+
+	paramParsers.RLock()
+	parser, ok := paramParsers.parsers[reflect.TypeOf((*T)(nil)).Elem()]
+	paramParsers.RUnlock()
+
+	if !ok {
+		return zero, fmt.Errorf("%w: %T", ErrNoParamParser, zero)
+	}
+
+	return parseParam(ctx, key, parser.(func(string) (T, error)))
+}
+
+// ParamInt retrieves from context the value of the parameter keyed by `key` as an int. See Param.
+func ParamInt(ctx context.Context, key string) (int, error) {
+	return parseParam(ctx, key, strconv.Atoi)
+}
+
+// ParamInt64 retrieves from context the value of the parameter keyed by `key` as an int64. See Param.
+func ParamInt64(ctx context.Context, key string) (int64, error) {
+	return parseParam(ctx, key, func(s string) (int64, error) {
+		return strconv.ParseInt(s, 10, 64)
+	})
+}
+
+// ParamUUID retrieves from context the value of the parameter keyed by `key` as a UUID. See Param.
+func ParamUUID(ctx context.Context, key string) (UUID, error) {
+	return parseParam(ctx, key, ParseUUID)
+}
+
+// ParamTime retrieves from context the value of the parameter keyed by `key` as a time.Time, parsed per the given
+// layout, e.g. time.RFC3339. See Param.
+func ParamTime(ctx context.Context, key string, layout string) (time.Time, error) {
+	return parseParam(ctx, key, func(s string) (time.Time, error) {
+		return time.Parse(layout, s)
+	})
+}
+
+// parseParam retrieves from context the value of the parameter keyed by `key`, parsed by `parse`.
+func parseParam[T any](ctx context.Context, key string, parse func(string) (T, error)) (T, error) {
+	var zero T
+
+	value, ok := ParamsFromContext(ctx).Lookup(key)
+	if !ok {
+		return zero, fmt.Errorf("%w: %s", ErrMissingParameter, key)
+	}
+
+	ret, err := parse(value)
+	if err != nil {
+		return zero, fmt.Errorf("%w: %s: %v", ErrInvalidParameter, key, err)
+	}
+
+	return ret, nil
+}
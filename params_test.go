@@ -0,0 +1,235 @@
+package turnpike
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParams(t *testing.T) {
+	params := []*parameter{
+		{key: "id", value: "12"},
+		{key: "filepath", value: ""},
+		{key: "user", value: "uxc"},
+	}
+
+	ctx := context.WithValue(context.Background(), parameterKey, params)
+	actual := ParamsFromContext(ctx)
+
+	t.Run("Len", func(t *testing.T) {
+		if actual.Len() != 3 {
+			t.Errorf("expected %d parameters but got %d", 3, actual.Len())
+		}
+	})
+
+	t.Run("Index", func(t *testing.T) {
+		if actual[2] != (Parameter{Key: "user", Value: "uxc"}) {
+			t.Errorf("expected parameter user=uxc but got %v", actual[2])
+		}
+	})
+
+	t.Run("All", func(t *testing.T) {
+		expected := []Parameter{{Key: "id", Value: "12"}, {Key: "filepath", Value: ""}, {Key: "user", Value: "uxc"}}
+		all := actual.All()
+
+		if !reflect.DeepEqual(all, expected) {
+			t.Errorf("expected %v but got %v", expected, all)
+		}
+
+		// Mutating the copy must not affect the parameters.
+		all[0].Value = "13"
+		if actual.Get("id") != "12" {
+			t.Error("expected All to return a copy")
+		}
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		if actual.Get("id") != "12" || actual.Get("missing") != "" {
+			t.Errorf("unexpected values %s, %s", actual.Get("id"), actual.Get("missing"))
+		}
+	})
+
+	t.Run("Lookup", func(t *testing.T) {
+		type testCase struct {
+			key   string
+			value string
+			ok    bool
+		}
+
+		for _, test := range []testCase{
+			{key: "id", value: "12", ok: true},
+			{key: "filepath", value: "", ok: true},
+			{key: "missing", value: "", ok: false},
+		} {
+			value, ok := actual.Lookup(test.key)
+			if value != test.value || ok != test.ok {
+				t.Errorf("expected (%q, %v) for key %s but got (%q, %v)", test.value, test.ok, test.key, value, ok)
+			}
+		}
+	})
+
+	t.Run("EmptyContext", func(t *testing.T) {
+		if params := ParamsFromContext(context.Background()); params.Len() != 0 {
+			t.Errorf("expected no parameters but got %v", params)
+		}
+	})
+}
+
+func TestParamsFromContext(t *testing.T) {
+	record := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, param := range ParamsFromContext(r.Context()).All() {
+			fmt.Fprintf(w, "%s=%s;", param.Key, param.Value)
+		}
+	})
+
+	billing := NewRouter()
+	billing.WithMethods(http.MethodGet).Handler("/invoices/:id/*rest", record).MustRegister()
+
+	r := NewRouter()
+	r.Mount("/tenants/:tenant/billing", billing)
+
+	tests := []testCase{
+		{
+			name:   "OrderedParameters",
+			path:   "/tenants/acme/billing/invoices/12/lines/3",
+			method: http.MethodGet,
+			code:   http.StatusOK,
+			body:   "tenant=acme;id=12;rest=lines/3;",
+		},
+	}
+
+	runHTTPTests(t, r, tests)
+}
+
+func TestTypedParams(t *testing.T) {
+	params := []*parameter{
+		{key: "id", value: "-42"},
+		{key: "big", value: "9007199254740993"},
+		{key: "uuid", value: "123E4567-e89b-12d3-a456-426614174000"},
+		{key: "date", value: "2024-02-29"},
+		{key: "flag", value: "true"},
+		{key: "name", value: "uxc"},
+	}
+
+	ctx := context.WithValue(context.Background(), parameterKey, params)
+
+	t.Run("ParamInt", func(t *testing.T) {
+		if v, err := ParamInt(ctx, "id"); err != nil || v != -42 {
+			t.Errorf("expected -42 but got %d (%v)", v, err)
+		}
+	})
+
+	t.Run("ParamInt64", func(t *testing.T) {
+		if v, err := ParamInt64(ctx, "big"); err != nil || v != 9007199254740993 {
+			t.Errorf("expected 9007199254740993 but got %d (%v)", v, err)
+		}
+	})
+
+	t.Run("ParamUUID", func(t *testing.T) {
+		v, err := ParamUUID(ctx, "uuid")
+		if err != nil || v.String() != "123e4567-e89b-12d3-a456-426614174000" {
+			t.Errorf("expected 123e4567-e89b-12d3-a456-426614174000 but got %s (%v)", v, err)
+		}
+	})
+
+	t.Run("ParamTime", func(t *testing.T) {
+		v, err := ParamTime(ctx, "date", "2006-01-02")
+		if err != nil || !v.Equal(time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("expected 2024-02-29 but got %s (%v)", v, err)
+		}
+	})
+
+	t.Run("Param", func(t *testing.T) {
+		if v, err := Param[bool](ctx, "flag"); err != nil || !v {
+			t.Errorf("expected true but got %v (%v)", v, err)
+		}
+
+		if v, err := Param[string](ctx, "name"); err != nil || v != "uxc" {
+			t.Errorf("expected uxc but got %s (%v)", v, err)
+		}
+	})
+
+	t.Run("RegisterParamParser", func(t *testing.T) {
+		type upper string
+
+		RegisterParamParser(func(s string) (upper, error) { return upper(strings.ToUpper(s)), nil })
+
+		if v, err := Param[upper](ctx, "name"); err != nil || v != "UXC" {
+			t.Errorf("expected UXC but got %s (%v)", v, err)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		type testCase struct {
+			name     string
+			err      error
+			expected error
+		}
+
+		_, missing := ParamInt(ctx, "missing")
+		_, invalid := ParamInt(ctx, "name")
+		_, invalidUUID := ParamUUID(ctx, "id")
+		_, invalidTime := ParamTime(ctx, "date", time.RFC3339)
+		_, noParser := Param[complex64](ctx, "id")
+
+		for _, test := range []testCase{
+			{name: "Missing", err: missing, expected: ErrMissingParameter},
+			{name: "Invalid", err: invalid, expected: ErrInvalidParameter},
+			{name: "InvalidUUID", err: invalidUUID, expected: ErrInvalidParameter},
+			{name: "InvalidTime", err: invalidTime, expected: ErrInvalidParameter},
+			{name: "NoParser", err: noParser, expected: ErrNoParamParser},
+		} {
+			if !errors.Is(test.err, test.expected) {
+				t.Errorf("%s: expected %v but got %v", test.name, test.expected, test.err)
+			}
+		}
+	})
+}
+
+func TestParamTypeAliases(t *testing.T) {
+	r := NewRouter()
+
+	r.WithMethods(http.MethodGet).Handler("/users/:id[uuid]", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := ParamUUID(r.Context(), "id")
+		fmt.Fprintf(w, "uuid=%s", id)
+	})).MustRegister()
+	r.WithMethods(http.MethodGet).Handler("/users/:id[int]", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := ParamInt(r.Context(), "id")
+		fmt.Fprintf(w, "int=%d", id)
+	})).MustRegister()
+
+	tests := []testCase{
+		{name: "Int", path: "/users/-7", method: http.MethodGet, code: http.StatusOK, body: "int=-7"},
+		{
+			name:   "UUID",
+			path:   "/users/123e4567-e89b-12d3-a456-426614174000",
+			method: http.MethodGet,
+			code:   http.StatusOK,
+			body:   "uuid=123e4567-e89b-12d3-a456-426614174000",
+		},
+		{name: "NoMatch", path: "/users/uxc", method: http.MethodGet, code: http.StatusNotFound, body: "404 page not found\n"},
+	}
+
+	runHTTPTests(t, r, tests)
+}
+
+func TestOptionalParams(t *testing.T) {
+	r := NewRouter()
+
+	r.WithMethods(http.MethodGet).Handler("/reports/:year?", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		year, ok := ParamsFromContext(r.Context()).Lookup("year")
+		fmt.Fprintf(w, "year=%q present=%v", year, ok)
+	})).MustRegister()
+
+	tests := []testCase{
+		{name: "Present", path: "/reports/2024", method: http.MethodGet, code: http.StatusOK, body: `year="2024" present=true`},
+		{name: "Absent", path: "/reports", method: http.MethodGet, code: http.StatusOK, body: `year="" present=false`},
+	}
+
+	runHTTPTests(t, r, tests)
+}
@@ -1,6 +1,10 @@
 package turnpike
 
 import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
 	"strings"
 )
 
@@ -11,8 +15,25 @@ const (
 	PatternDelimiterStart = "["
 	PatternDelimiterEnd   = "]"
 	PatternWildcard       = "(.+)"
+	CatchAllDelimiter     = "*"
+	OptionalDelimiter     = "?"
+	// PiecePatternWildcard is the default pattern of a parameter within a composite segment. It matches lazily, such
+	// that any literal text following the parameter delimits it.
+	PiecePatternWildcard = ".+?"
 )
 
+// patternAliases maps the names of built-in parameter types to the regex patterns they denote.
+// e.g. :id[int] is equivalent to :id[^-?\d+$]
+var patternAliases = map[string]string{
+	"int":   `^-?\d+$`,
+	"uint":  `^\d+$`,
+	"float": `^-?\d+(\.\d+)?$`,
+	"uuid":  `^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`,
+	"alpha": `^[a-zA-Z]+$`,
+	"alnum": `^[a-zA-Z0-9]+$`,
+	"slug":  `^[a-z0-9]+(-[a-z0-9]+)*$`,
+}
+
 // expandPath separates a PathDelimiter-delimited string into a slice of strings.
 func expandPath(path string) []string {
 	var r []string
@@ -26,25 +47,67 @@ func expandPath(path string) []string {
 	return r
 }
 
-// deriveLabelPattern derives from a given label a regex pattern.
+// deriveLabelPattern derives from a given label a regex pattern, resolving built-in parameter types (see patternAliases).
 // e.g. :id[^\d+$] => ^\d+$
 // e.g. :id => (.+)
+// e.g. :name[^[a-z]+$] => ^[a-z]+$
+// e.g. :id[uint] => ^\d+$
 func deriveLabelPattern(label string) string {
 	start := strings.Index(label, PatternDelimiterStart)
-	end := strings.Index(label, PatternDelimiterEnd)
+	// The pattern may itself contain delimiters, e.g. a character class.
+	end := strings.LastIndex(label, PatternDelimiterEnd)
 
 	// If the label doesn't contain a pattern, default to the wildcard pattern.
 	if start == -1 || end == -1 {
 		return PatternWildcard
 	}
 
-	return label[start+1 : end]
+	pattern := label[start+1 : end]
+	if alias, ok := patternAliases[pattern]; ok {
+		return alias
+	}
+
+	return pattern
+}
+
+// validateParameter validates the syntax of a given parameter label: it must name a key, and any pattern must be
+// terminated and followed by nothing but an OptionalDelimiter.
+// e.g. :id, :id?, :id[^\d+$], :id[int]?
+func validateParameter(label string) error {
+	trimmed := strings.TrimSuffix(label, OptionalDelimiter)
+
+	start := strings.Index(trimmed, PatternDelimiterStart)
+	if start == -1 {
+		start = len(trimmed)
+	}
+
+	if start == len(ParameterDelimiter) {
+		return fmt.Errorf("%w: unnamed parameter in segment %s", ErrInvalidPattern, label)
+	}
+
+	if start == len(trimmed) {
+		return nil
+	}
+
+	end := matchPatternDelimiter(trimmed, start)
+	if end == -1 {
+		return fmt.Errorf("%w: unterminated pattern in segment %s", ErrInvalidPattern, label)
+	}
+
+	if end != len(trimmed)-1 {
+		return fmt.Errorf("%w: trailing text after pattern in segment %s", ErrInvalidPattern, label)
+	}
+
+	return nil
 }
 
 // deriveParameterKey derives from a given label a regex pattern.
 // e.g. :id[^\d+$] → id
 // e.g. :id        → id
+// e.g. :id?       → id
 func deriveParameterKey(label string) string {
+	label = strings.TrimSuffix(label, OptionalDelimiter)
+
 	start := strings.Index(label, ParameterDelimiter)
 	end := strings.Index(label, PatternDelimiterStart)
 
@@ -54,3 +117,355 @@ func deriveParameterKey(label string) string {
 
 	return label[start+1 : end]
 }
+
+// isParameter determines whether the given label is a parameter segment.
+// e.g. :id[^\d+$]
+func isParameter(label string) bool {
+	return strings.HasPrefix(label, ParameterDelimiter)
+}
+
+// isOptional determines whether the given label is an optional parameter segment, which also matches an absent
+// path segment.
+// e.g. :year?, :year[^\d{4}$]?
+func isOptional(label string) bool {
+	return isParameter(label) && !isComposite(label) && strings.HasSuffix(label, OptionalDelimiter)
+}
+
+// segmentPiece represents a piece of a composite segment: either literal text or a parameter.
+type segmentPiece struct {
+	literal string
+	key     string
+	// pattern holds the parameter's regex pattern, if constrained.
+	pattern string
+}
+
+// isComposite determines whether the given label is a composite segment, which holds several parameters or mixes
+// literal text and parameters. A label that begins with the only ParameterDelimiter it holds is a parameter segment.
+// e.g. :name.:ext, v:major.:minor, :year-:month
+func isComposite(label string) bool {
+	if isCatchAll(label) {
+		return false
+	}
+
+	n := countParameters(label)
+
+	return n > 1 || n == 1 && !isParameter(label)
+}
+
+// countParameters counts the ParameterDelimiters in the given label, disregarding any within patterns.
+func countParameters(label string) int {
+	n, depth := 0, 0
+
+	for i := 0; i < len(label); i++ {
+		switch label[i] {
+		case '\\':
+			if depth > 0 {
+				i++
+			}
+		case PatternDelimiterStart[0]:
+			depth++
+		case PatternDelimiterEnd[0]:
+			if depth > 0 {
+				depth--
+			}
+		case ParameterDelimiter[0]:
+			if depth == 0 {
+				n++
+			}
+		}
+	}
+
+	return n
+}
+
+// parseComposite parses a composite segment into its pieces. Parameter keys consist of letters, digits and
+// underscores; a parameter may be constrained by a pattern as in a parameter segment. Adjacent parameters are
+// invalid, as nothing would delimit them.
+// e.g. v:major[int].:minor → [v, major (^-?\d+$), ., minor]
+func parseComposite(label string) ([]segmentPiece, error) {
+	var pieces []segmentPiece
+	keys := make(map[string]bool)
+
+	for i := 0; i < len(label); {
+		if label[i] != ParameterDelimiter[0] {
+			end := strings.Index(label[i:], ParameterDelimiter)
+			if end == -1 {
+				end = len(label) - i
+			}
+
+			pieces = append(pieces, segmentPiece{literal: label[i : i+end]})
+			i += end
+			continue
+		}
+
+		j := i + 1
+		for j < len(label) && isKeyChar(label[j]) {
+			j++
+		}
+
+		piece := segmentPiece{key: label[i+1 : j]}
+		if piece.key == "" {
+			return nil, fmt.Errorf("%w: unnamed parameter in segment %s", ErrInvalidPattern, label)
+		}
+
+		if keys[piece.key] {
+			return nil, fmt.Errorf("%w: duplicate parameter %s in segment %s", ErrInvalidPattern, piece.key, label)
+		}
+
+		if n := len(pieces); n > 0 && pieces[n-1].key != "" {
+			return nil, fmt.Errorf("%w: adjacent parameters in segment %s", ErrInvalidPattern, label)
+		}
+
+		if j < len(label) && label[j] == PatternDelimiterStart[0] {
+			end := matchPatternDelimiter(label, j)
+			if end == -1 {
+				return nil, fmt.Errorf("%w: unterminated pattern in segment %s", ErrInvalidPattern, label)
+			}
+
+			piece.pattern = label[j+1 : end]
+			if alias, ok := patternAliases[piece.pattern]; ok {
+				piece.pattern = alias
+			}
+
+			j = end + 1
+		}
+
+		keys[piece.key] = true
+		pieces = append(pieces, piece)
+		i = j
+	}
+
+	return pieces, nil
+}
+
+// isKeyChar determines whether the given character may be part of a parameter key in a composite segment.
+func isKeyChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// matchPatternDelimiter returns the index of the PatternDelimiterEnd that closes the PatternDelimiterStart at index
+// `start` of the given label, or -1 if there is none. Nested and escaped delimiters are accounted for.
+func matchPatternDelimiter(label string, start int) int {
+	depth := 0
+
+	for i := start; i < len(label); i++ {
+		switch label[i] {
+		case '\\':
+			i++
+		case PatternDelimiterStart[0]:
+			depth++
+		case PatternDelimiterEnd[0]:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// expr returns the regex expression a parameter piece matches, stripped of any anchors.
+// e.g. ^\d+$ → (?:\d+)
+func (p segmentPiece) expr() string {
+	if p.pattern == "" {
+		return PiecePatternWildcard
+	}
+
+	expr := strings.TrimPrefix(p.pattern, "^")
+	if strings.HasSuffix(expr, "$") && !strings.HasSuffix(expr, "\\$") {
+		expr = strings.TrimSuffix(expr, "$")
+	}
+
+	return "(?:" + expr + ")"
+}
+
+// compositePattern derives from the pieces of a composite segment an anchored regex pattern matching the segment.
+// If `named`, each parameter is captured by a group named for its key; otherwise by an unnamed group, such that
+// segments differing only in their parameter keys derive the same pattern.
+// e.g. [name, ., ext] → ^(?P<name>.+?)\.(?P<ext>.+?)$
+func compositePattern(pieces []segmentPiece, named bool) string {
+	var b strings.Builder
+
+	b.WriteString("^")
+
+	for _, piece := range pieces {
+		if piece.key == "" {
+			b.WriteString(regexp.QuoteMeta(piece.literal))
+			continue
+		}
+
+		if named {
+			fmt.Fprintf(&b, "(?P<%s>%s)", piece.key, piece.expr())
+		} else {
+			fmt.Fprintf(&b, "(%s)", piece.expr())
+		}
+	}
+
+	b.WriteString("$")
+
+	return b.String()
+}
+
+// isCatchAll determines whether the given label is a catch-all segment.
+// e.g. *filepath
+func isCatchAll(label string) bool {
+	return strings.HasPrefix(label, CatchAllDelimiter)
+}
+
+// deriveCatchAllKey derives from a given catch-all label its parameter key.
+// e.g. *filepath → filepath
+func deriveCatchAllKey(label string) string {
+	return strings.TrimPrefix(label, CatchAllDelimiter)
+}
+
+// validateCatchAll ensures a catch-all segment in the given path is both named and the path's final segment.
+func validateCatchAll(path string) error {
+	segments := expandPath(path)
+
+	for i, segment := range segments {
+		if !isCatchAll(segment) {
+			continue
+		}
+
+		if deriveCatchAllKey(segment) == "" {
+			return fmt.Errorf("%w: catch-all segment in path %s must be named", ErrInvalidPattern, path)
+		}
+
+		if i != len(segments)-1 {
+			return fmt.Errorf("%w: catch-all segment %s must be the final segment of path %s", ErrInvalidPattern, segment, path)
+		}
+	}
+
+	return nil
+}
+
+// joinRemainder joins the given remainder path segments, retaining the trailing PathDelimiter of `path`, if extant.
+// e.g. ([css, app.css], /static/css/app.css) → css/app.css
+// e.g. ([css], /static/css/)                 → css/
+func joinRemainder(segments []string, path string) string {
+	remainder := strings.Join(segments, PathDelimiter)
+	if len(segments) > 0 && strings.HasSuffix(path, PathDelimiter) {
+		remainder += PathDelimiter
+	}
+
+	return remainder
+}
+
+// cleanPath returns the canonical form of a request path per path.Clean, retaining a trailing PathDelimiter.
+// e.g. //foo/./bar/../baz/ → /foo/baz/
+// e.g. ""                 → /
+func cleanPath(p string) string {
+	clean := path.Clean(PathDelimiter + p)
+
+	if strings.HasSuffix(p, PathDelimiter) && clean != PathRoot {
+		clean += PathDelimiter
+	}
+
+	return clean
+}
+
+// joinPath joins a path prefix and a path, yielding a PathDelimiter-prefixed path.
+// e.g. (/api, /users) → /api/users
+// e.g. (/api/, /)     → /api
+func joinPath(prefix string, path string) string {
+	prefix = strings.Trim(prefix, PathDelimiter)
+	path = strings.TrimPrefix(path, PathDelimiter)
+
+	if prefix == "" {
+		return PathDelimiter + path
+	}
+
+	if path == "" {
+		return PathDelimiter + prefix
+	}
+
+	return PathDelimiter + prefix + PathDelimiter + path
+}
+
+// buildPath builds a path from the given path pattern, substituting each parameter, composite segment parameter and
+// catch-all segment with its value. Values are validated against their segment's pattern and path-escaped.
+// An optional parameter segment without a value is omitted.
+// e.g. (/users/:id[^\d+$], {id: 42}) → /users/42
+func buildPath(pattern string, values map[string]string) (string, error) {
+	segments := expandPath(pattern)
+	built := make([]string, 0, len(segments))
+
+	for _, segment := range segments {
+		switch {
+		case isComposite(segment):
+			pieces, err := parseComposite(segment)
+			if err != nil {
+				return "", err
+			}
+
+			var b strings.Builder
+
+			for _, piece := range pieces {
+				if piece.key == "" {
+					b.WriteString(piece.literal)
+					continue
+				}
+
+				value, ok := values[piece.key]
+				if !ok {
+					return "", fmt.Errorf("%w: %s", ErrMissingParameter, piece.key)
+				}
+
+				regex, err := rc.get("^" + piece.expr() + "$")
+				if err != nil || !regex.MatchString(value) {
+					return "", fmt.Errorf("%w: %s does not match %s", ErrInvalidParameter, value, segment)
+				}
+
+				b.WriteString(url.PathEscape(value))
+			}
+
+			built = append(built, b.String())
+
+		case isCatchAll(segment):
+			key := deriveCatchAllKey(segment)
+
+			value, ok := values[key]
+			if !ok {
+				return "", fmt.Errorf("%w: %s", ErrMissingParameter, key)
+			}
+
+			// A catch-all value may span several segments; escape each.
+			parts := strings.Split(value, PathDelimiter)
+			for j := range parts {
+				parts[j] = url.PathEscape(parts[j])
+			}
+
+			built = append(built, strings.Join(parts, PathDelimiter))
+
+		case isParameter(segment):
+			key := deriveParameterKey(segment)
+
+			value, ok := values[key]
+			if !ok && isOptional(segment) {
+				continue
+			}
+
+			if !ok {
+				return "", fmt.Errorf("%w: %s", ErrMissingParameter, key)
+			}
+
+			regex, err := rc.get(deriveLabelPattern(segment))
+			if err != nil || !regex.MatchString(value) {
+				return "", fmt.Errorf("%w: %s does not match %s", ErrInvalidParameter, value, segment)
+			}
+
+			built = append(built, url.PathEscape(value))
+
+		default:
+			built = append(built, segment)
+		}
+	}
+
+	path := joinPath("", strings.Join(built, PathDelimiter))
+	if strings.HasSuffix(pattern, PathDelimiter) && path != PathRoot {
+		path += PathDelimiter
+	}
+
+	return path, nil
+}
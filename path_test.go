@@ -1,6 +1,8 @@
 package turnpike
 
 import (
+	"errors"
+	"reflect"
 	"testing"
 )
 
@@ -40,6 +42,9 @@ func TestDeriveLabelPattern(t *testing.T) {
 		{name: "NoRegex", input: ":id", expected: "(.+)"},
 		{name: "LiteralRegex", input: ":id[xxx]", expected: "xxx"},
 		{name: "WildcardRegex", input: ":id[*]", expected: "*"},
+		{name: "CharacterClassRegex", input: ":name[^[a-z]+$]", expected: "^[a-z]+$"},
+		{name: "IntAlias", input: ":id[int]", expected: "^-?\\d+$"},
+		{name: "UUIDAlias", input: ":id[uuid]", expected: patternAliases["uuid"]},
 	}
 
 	for _, test := range tests {
@@ -64,6 +69,8 @@ func TestDeriveParameterKey(t *testing.T) {
 		{name: "BasicKeyEmptyRegex", input: ":val[]", expected: "val"},
 		{name: "BasicKeyWildcardRegex", input: ":ex[(.*)]", expected: "ex"},
 		{name: "BasicKeyNoRegex", input: ":id", expected: "id"},
+		{name: "OptionalKey", input: ":id?", expected: "id"},
+		{name: "OptionalKeyRegex", input: ":id[^\\d+$]?", expected: "id"},
 	}
 
 	for _, test := range tests {
@@ -75,6 +82,205 @@ func TestDeriveParameterKey(t *testing.T) {
 	}
 }
 
+func TestValidateParameter(t *testing.T) {
+	type testCase struct {
+		name     string
+		input    string
+		expected error
+	}
+
+	tests := []testCase{
+		{name: "Key", input: ":id"},
+		{name: "OptionalKey", input: ":id?"},
+		{name: "Pattern", input: ":id[^\\d+$]"},
+		{name: "NestedPattern", input: ":name[^[a-z]+$]"},
+		{name: "OptionalPattern", input: ":id[int]?"},
+		{name: "UnnamedParameter", input: ":", expected: ErrInvalidPattern},
+		{name: "UnnamedPattern", input: ":[^\\d+$]", expected: ErrInvalidPattern},
+		{name: "UnterminatedPattern", input: ":id[^\\d+$", expected: ErrInvalidPattern},
+		{name: "TrailingText", input: ":id[int]x", expected: ErrInvalidPattern},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := validateParameter(test.input); !errors.Is(err, test.expected) {
+				t.Errorf("expected error %v but got %v\n", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestCleanPath(t *testing.T) {
+	type testCase struct {
+		name     string
+		input    string
+		expected string
+	}
+
+	tests := []testCase{
+		{name: "CleanPath", input: "/foo/bar", expected: "/foo/bar"},
+		{name: "EmptyPath", input: "", expected: "/"},
+		{name: "RootPath", input: "/", expected: "/"},
+		{name: "RedundantDelimiters", input: "//foo//bar", expected: "/foo/bar"},
+		{name: "TrailingDelimiter", input: "/foo/", expected: "/foo/"},
+		{name: "RedundantTrailingDelimiters", input: "/foo//", expected: "/foo/"},
+		{name: "DotSegments", input: "/foo/./bar/../baz", expected: "/foo/baz"},
+		{name: "EscapingDotSegments", input: "/../foo", expected: "/foo"},
+		{name: "UndelimitedPath", input: "foo", expected: "/foo"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := cleanPath(test.input); actual != test.expected {
+				t.Errorf("expected %s but got %s\n", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestJoinPath(t *testing.T) {
+	type testCase struct {
+		name     string
+		prefix   string
+		path     string
+		expected string
+	}
+
+	tests := []testCase{
+		{name: "BasicJoin", prefix: "/api", path: "/users", expected: "/api/users"},
+		{name: "TrailingPrefix", prefix: "/api/", path: "/users", expected: "/api/users"},
+		{name: "RootPath", prefix: "/api", path: "/", expected: "/api"},
+		{name: "EmptyPrefix", prefix: "", path: "/users", expected: "/users"},
+		{name: "EmptyPrefixRootPath", prefix: "", path: "/", expected: "/"},
+		{name: "UndelimitedSegments", prefix: "api", path: "users", expected: "/api/users"},
+		{name: "TrailingPath", prefix: "/api", path: "/users/", expected: "/api/users/"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := joinPath(test.prefix, test.path); actual != test.expected {
+				t.Errorf("expected %s but got %s\n", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDeriveCatchAllKey(t *testing.T) {
+	type testCase struct {
+		name     string
+		input    string
+		expected string
+	}
+
+	tests := []testCase{
+		{name: "BasicKey", input: "*filepath", expected: "filepath"},
+		{name: "EmptyKey", input: "*", expected: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := deriveCatchAllKey(test.input); actual != test.expected {
+				t.Errorf("expected %s but got %s\n", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestValidateCatchAll(t *testing.T) {
+	type testCase struct {
+		name    string
+		input   string
+		invalid bool
+	}
+
+	tests := []testCase{
+		{name: "NoCatchAll", input: "/static/:file", invalid: false},
+		{name: "TrailingCatchAll", input: "/static/*filepath", invalid: false},
+		{name: "UnnamedCatchAll", input: "/static/*", invalid: true},
+		{name: "InnerCatchAll", input: "/static/*filepath/more", invalid: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateCatchAll(test.input)
+			if test.invalid && err == nil {
+				t.Errorf("expected path %s to be invalid", test.input)
+			}
+
+			if !test.invalid && err != nil {
+				t.Errorf("expected path %s to be valid but got %v", test.input, err)
+			}
+		})
+	}
+}
+
+func TestJoinRemainder(t *testing.T) {
+	type testCase struct {
+		name     string
+		segments []string
+		path     string
+		expected string
+	}
+
+	tests := []testCase{
+		{name: "BasicRemainder", segments: []string{"css", "app.css"}, path: "/static/css/app.css", expected: "css/app.css"},
+		{name: "TrailingRemainder", segments: []string{"css"}, path: "/static/css/", expected: "css/"},
+		{name: "EmptyRemainder", segments: nil, path: "/static/", expected: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := joinRemainder(test.segments, test.path); actual != test.expected {
+				t.Errorf("expected %s but got %s\n", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestBuildPath(t *testing.T) {
+	type testCase struct {
+		name     string
+		pattern  string
+		values   map[string]string
+		expected string
+		err      error
+	}
+
+	tests := []testCase{
+		{name: "StaticPath", pattern: "/foo/bar", expected: "/foo/bar"},
+		{name: "RootPath", pattern: "/", expected: "/"},
+		{name: "TrailingDelimiter", pattern: "/foo/:id/", values: map[string]string{"id": "1"}, expected: "/foo/1/"},
+		{name: "Parameters", pattern: "/foo/:id/:user", values: map[string]string{"id": "42", "user": "alice"}, expected: "/foo/42/alice"},
+		{name: "RegexParameter", pattern: "/foo/:id[^\\d+$]", values: map[string]string{"id": "42"}, expected: "/foo/42"},
+		{name: "EscapedParameter", pattern: "/foo/:q", values: map[string]string{"q": "a b/c"}, expected: "/foo/a%20b%2Fc"},
+		{name: "CatchAll", pattern: "/static/*filepath", values: map[string]string{"filepath": "css/a b.css"}, expected: "/static/css/a%20b.css"},
+		{name: "MissingParameter", pattern: "/foo/:id/:user", values: map[string]string{"id": "42"}, err: ErrMissingParameter},
+		{name: "MissingCatchAll", pattern: "/static/*filepath", err: ErrMissingParameter},
+		{name: "InvalidParameter", pattern: "/foo/:id[^\\d+$]", values: map[string]string{"id": "abc"}, err: ErrInvalidParameter},
+		{name: "EmptyParameter", pattern: "/foo/:id", values: map[string]string{"id": ""}, err: ErrInvalidParameter},
+		{name: "Composite", pattern: "/files/:name.:ext", values: map[string]string{"name": "a b", "ext": "pdf"}, expected: "/files/a%20b.pdf"},
+		{name: "CompositeConstraint", pattern: "/v:major[int]/x", values: map[string]string{"major": "2"}, expected: "/v2/x"},
+		{name: "InvalidComposite", pattern: "/v:major[int]/x", values: map[string]string{"major": "two"}, err: ErrInvalidParameter},
+		{name: "OmittedOptional", pattern: "/reports/:year?/:month?", values: map[string]string{"year": "2024"}, expected: "/reports/2024"},
+		{name: "OmittedInnerOptional", pattern: "/docs/:lang?/:page/", values: map[string]string{"page": "intro"}, expected: "/docs/intro/"},
+		{name: "InvalidOptional", pattern: "/reports/:year[^\\d{4}$]?", values: map[string]string{"year": "24"}, err: ErrInvalidParameter},
+		{name: "MissingComposite", pattern: "/files/:name.:ext", values: map[string]string{"name": "a"}, err: ErrMissingParameter},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := buildPath(test.pattern, test.values)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected error %v but got %v", test.err, err)
+			}
+
+			if actual != test.expected {
+				t.Errorf("expected %s but got %s\n", test.expected, actual)
+			}
+		})
+	}
+}
+
 func areSlicesEqByValue(a []string, b []string) bool {
 	if len(a) != len(b) {
 		return false
@@ -88,3 +294,64 @@ func areSlicesEqByValue(a []string, b []string) bool {
 
 	return true
 }
+
+func TestParseComposite(t *testing.T) {
+	type testCase struct {
+		name     string
+		input    string
+		expected []segmentPiece
+		err      error
+	}
+
+	tests := []testCase{
+		{
+			name:     "Parameters",
+			input:    ":name.:ext",
+			expected: []segmentPiece{{key: "name"}, {literal: "."}, {key: "ext"}},
+		},
+		{
+			name:     "LeadingLiteral",
+			input:    "v:major.:minor",
+			expected: []segmentPiece{{literal: "v"}, {key: "major"}, {literal: "."}, {key: "minor"}},
+		},
+		{
+			name:     "Patterns",
+			input:    ":year[^\\d{4}$]-:month[int]",
+			expected: []segmentPiece{{key: "year", pattern: "^\\d{4}$"}, {literal: "-"}, {key: "month", pattern: patternAliases["int"]}},
+		},
+		{
+			name:     "NestedPatternDelimiters",
+			input:    ":a[[a-z]+]_:b",
+			expected: []segmentPiece{{key: "a", pattern: "[a-z]+"}, {literal: "_"}, {key: "b"}},
+		},
+		{name: "AdjacentParameters", input: ":a:b", err: ErrInvalidPattern},
+		{name: "UnnamedParameter", input: "v:.:minor", err: ErrInvalidPattern},
+		{name: "DuplicateParameter", input: ":a-:a", err: ErrInvalidPattern},
+		{name: "UnterminatedPattern", input: ":a[\\d+-:b", err: ErrInvalidPattern},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := parseComposite(test.input)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected error %v but got %v", test.err, err)
+			}
+
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected %v but got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCompositePattern(t *testing.T) {
+	pieces := []segmentPiece{{key: "name"}, {literal: "."}, {key: "ext", pattern: "^[a-z]+$"}}
+
+	if actual, expected := compositePattern(pieces, true), "^(?P<name>.+?)\\.(?P<ext>(?:[a-z]+))$"; actual != expected {
+		t.Errorf("expected %s but got %s", expected, actual)
+	}
+
+	if actual, expected := compositePattern(pieces, false), "^(.+?)\\.((?:[a-z]+))$"; actual != expected {
+		t.Errorf("expected %s but got %s", expected, actual)
+	}
+}
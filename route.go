@@ -0,0 +1,235 @@
+package turnpike
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+// DefaultFileParameter is the name of the catch-all segment FileHandler appends to its path.
+const DefaultFileParameter = "filepath"
+
+// Route represents a route record to be used by a Router.
+type Route struct {
+	methods       []string
+	path          string
+	handler       http.Handler
+	middlewares   middlewares
+	isFileHandler bool
+	name          string
+	// host holds the pattern of the host the Route record is served for, if any.
+	host string
+	// matchers holds the RequestMatchers a request must satisfy to be served by the Route record.
+	matchers []RequestMatcher
+	// produces holds the media types the Route record produces, if declared.
+	produces []string
+	// version holds the API version the Route record is registered against, if any.
+	version *Version
+}
+
+// RouteBuilder builds a single Route record. Every RouteBuilder owns its own Route record, which
+// allows routes to be built concurrently and across several Routers.
+type RouteBuilder struct {
+	router *Router
+	group  *Group
+	route  *Route
+	// err holds the first error encountered while building the Route record, if any.
+	err error
+}
+
+// newRouteBuilder constructs and returns a pointer to a new RouteBuilder bound to the given Router.
+func newRouteBuilder(r *Router) *RouteBuilder {
+	return &RouteBuilder{
+		router: r,
+		route:  &Route{},
+	}
+}
+
+// Use adds middlewares to the Route record.
+func (rb *RouteBuilder) Use(mws ...middleware) *RouteBuilder {
+	rb.route.middlewares = append(rb.route.middlewares, newMiddlewares(mws)...)
+
+	return rb
+}
+
+// WithMethods appends user-specified HTTP methods to the Route record.
+func (rb *RouteBuilder) WithMethods(methods ...string) *RouteBuilder {
+	rb.route.methods = append(rb.route.methods, methods...)
+
+	return rb
+}
+
+// Name names the Route record, such that its URL may be built with Router.URL.
+func (rb *RouteBuilder) Name(name string) *RouteBuilder {
+	rb.route.name = name
+
+	return rb
+}
+
+// Headers adds a RequestMatcher to the Route record that requires requests to bear each of the given header
+// key-value pairs, e.g. Headers("Content-Type", "application/json", "X-API-Version", "2"). Header values are compared
+// disregarding case and any parameters, such that application/json matches application/json; charset=utf-8.
+// An empty value requires only that the header be present.
+func (rb *RouteBuilder) Headers(pairs ...string) *RouteBuilder {
+	matcher, err := newHeaderMatcher(pairs)
+	if err != nil {
+		rb.fail(err)
+		return rb
+	}
+
+	return rb.MatcherFunc(matcher)
+}
+
+// Queries adds a RequestMatcher to the Route record that requires requests to bear each of the given query
+// key-value pairs, e.g. Queries("format", "csv"). An empty value requires only that the query key be present.
+func (rb *RouteBuilder) Queries(pairs ...string) *RouteBuilder {
+	matcher, err := newQueryMatcher(pairs)
+	if err != nil {
+		rb.fail(err)
+		return rb
+	}
+
+	return rb.MatcherFunc(matcher)
+}
+
+// Schemes adds a RequestMatcher to the Route record that requires requests to be made via any of the given
+// URL schemes, e.g. Schemes("https"). Absent an absolute request URL, the scheme is https if the request was
+// received over TLS and http otherwise.
+func (rb *RouteBuilder) Schemes(schemes ...string) *RouteBuilder {
+	return rb.MatcherFunc(newSchemeMatcher(schemes))
+}
+
+// MatcherFunc adds a RequestMatcher to the Route record.
+//
+// Several Route records may share an HTTP method and path if they have RequestMatchers. A request is served by
+// the first such Route record, in order of registration, whose RequestMatchers it satisfies, or else by the Route
+// record without RequestMatchers, if extant. A request that satisfies none is served by the Router's
+// NoMatchHandler.
+func (rb *RouteBuilder) MatcherFunc(matcher RequestMatcher) *RouteBuilder {
+	if matcher == nil {
+		rb.fail(fmt.Errorf("%w: nil RequestMatcher", ErrInvalidMatcher))
+		return rb
+	}
+
+	rb.route.matchers = append(rb.route.matchers, matcher)
+
+	return rb
+}
+
+// Produces declares the media types the Route record produces, e.g. Produces("application/json", "text/csv").
+//
+// Several Route records may share an HTTP method and path if they declare the media types they produce. The Router
+// serves a request with the Route record producing the media type the request's Accept header prefers per
+// RFC 9110, available to the handler via NegotiatedMediaType. Of media types of equal preference, that declared first,
+// by the Route record registered first, is served. Requests that accept none of the media types are served by the
+// Route record declaring none, if extant, or else by the Router's NotAcceptableHandler. RequestMatchers are
+// evaluated before media types. A Route record without RequestMatchers that produces the same media types as another
+// for the same HTTP method, path and API version is a duplicate. If the Router's VersionMediaType is enabled, media ranges naming an API version also
+// accept their unversioned media type, e.g. application/vnd.acme.v2+json accepts application/json.
+func (rb *RouteBuilder) Produces(mediaTypes ...string) *RouteBuilder {
+	for _, mediaType := range mediaTypes {
+		if _, _, err := mime.ParseMediaType(mediaType); err != nil {
+			rb.fail(fmt.Errorf("%w: media type %s: %v", ErrInvalidMatcher, mediaType, err))
+			return rb
+		}
+	}
+
+	rb.route.produces = append(rb.route.produces, mediaTypes...)
+
+	return rb
+}
+
+// fail records the given error, unless an error was already recorded. Register returns the recorded error.
+func (rb *RouteBuilder) fail(err error) {
+	if rb.err == nil {
+		rb.err = err
+	}
+}
+
+// Handler adds a path and handler to the Route record.
+func (rb *RouteBuilder) Handler(path string, handler http.Handler) *RouteBuilder {
+	rb.route.path = path
+	rb.route.handler = handler
+
+	return rb
+}
+
+// FileHandler registers a route handler as a file server, serving the directory tree of `root` beneath `path`.
+// Unless the path already ends in a catch-all segment, a catch-all segment named DefaultFileParameter is
+// appended to it; the catch-all's value qualifies the file served.
+// e.g. FileHandler("/static", http.Dir("public")) serves /static/css/app.css from public/css/app.css
+// This method effectively replaces the `Handler()` stage of the route pipeline.
+// @todo allow usage of custom NotFoundHandler
+func (rb *RouteBuilder) FileHandler(path string, root http.FileSystem) *RouteBuilder {
+	fileServer := http.FileServer(root)
+
+	key := DefaultFileParameter
+	if segments := expandPath(path); len(segments) > 0 && isCatchAll(segments[len(segments)-1]) {
+		key = deriveCatchAllKey(segments[len(segments)-1])
+	} else {
+		path = joinPath(path, CatchAllDelimiter+key)
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fileServer.ServeHTTP(w, withPath(req, PathDelimiter+GetParam(req.Context(), key)))
+	})
+
+	rb.route.isFileHandler = true
+	return rb.Handler(path, handler).WithMethods(http.MethodGet)
+}
+
+// Register registers the Route record with the Router. This method must be invoked to register the Route.
+// Register returns a *RouteError if the Route record is incomplete or invalid, or conflicts with a registered Route.
+func (rb *RouteBuilder) Register() error {
+	route := rb.route
+
+	if rb.err != nil {
+		return newRouteError(route, rb.err)
+	}
+
+	if route.path == "" {
+		return newRouteError(route, ErrNoPath)
+	}
+
+	if route.handler == nil {
+		return newRouteError(route, ErrNoHandler)
+	}
+
+	route = rb.resolve()
+
+	if len(route.methods) == 0 {
+		return newRouteError(route, ErrNoMethods)
+	}
+
+	if route.isFileHandler && len(route.methods) > 1 {
+		return newRouteError(route, ErrFileHandlerMethods)
+	}
+
+	return rb.router.register(route)
+}
+
+// MustRegister registers the Route record with the Router, as Register does, but panics if registration fails.
+func (rb *RouteBuilder) MustRegister() {
+	if err := rb.Register(); err != nil {
+		panic(err)
+	}
+}
+
+// resolve returns a copy of the Route record with the Group's prefix, middlewares and default HTTP methods
+// applied, if extant.
+func (rb *RouteBuilder) resolve() *Route {
+	route := *rb.route
+
+	if g := rb.group; g != nil {
+		route.host = g.hostPattern()
+		route.version = g.version
+		route.path = joinPath(g.prefix, route.path)
+		route.middlewares = append(newMiddlewares(g.middlewares), route.middlewares...)
+
+		if len(route.methods) == 0 {
+			route.methods = append([]string(nil), g.methods...)
+		}
+	}
+
+	return &route
+}
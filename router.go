@@ -2,7 +2,42 @@ package turnpike
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PathPolicy represents how a Router treats request paths that differ from their canonical form.
+// The canonical form of a path is built per path.Clean; it has no empty or dot segments and carries a trailing
+// PathDelimiter only if the matched Route record was registered with one.
+type PathPolicy int
+
+const (
+	// PathLenient matches request paths regardless of their form, e.g. /foo/, //foo and /bar/../foo all match /foo.
+	PathLenient PathPolicy = iota
+	// PathStrict matches only request paths in their canonical form.
+	PathStrict
+	// PathRedirect redirects request paths to their canonical form, with status 301 for GET and HEAD requests
+	// and status 308 otherwise.
+	PathRedirect
+)
+
+// CasePolicy represents how a Router treats the case of static path segments.
+// Path parameter values retain their case under every CasePolicy.
+type CasePolicy int
+
+const (
+	// CaseSensitive matches static path segments only in their registered case.
+	CaseSensitive CasePolicy = iota
+	// CaseInsensitive matches static path segments regardless of case, e.g. /Users/42 matches /users/:id.
+	// A match in the registered case takes precedence.
+	CaseInsensitive
+	// CaseRedirect matches static path segments as CaseInsensitive does, but redirects requests whose static
+	// path segments differ in case to the canonical path in the registered case.
+	CaseRedirect
 )
 
 // Router represents a multiplexer that routes HTTP requests.
@@ -10,122 +45,562 @@ type Router struct {
 	trie                    *trie
 	NotFoundHandler         http.Handler
 	MethodNotAllowedHandler http.Handler
-}
-
-// Route represents a route record to be used by a Router.
-type Route struct {
-	methods       []string
-	path          string
-	handler       http.Handler
+	// NoMatchHandler serves requests whose path and HTTP method match Route records whose RequestMatchers the
+	// request satisfies none of. See RouteBuilder.MatcherFunc.
+	NoMatchHandler http.Handler
+	// NotAcceptableHandler serves requests that accept none of the media types produced by the Route records their
+	// path and HTTP method match. See RouteBuilder.Produces.
+	NotAcceptableHandler http.Handler
+	// HandleOPTIONS enables automatic responses to OPTIONS requests for any registered path that has no
+	// OPTIONS handler of its own. The Allow header of such responses lists the path's HTTP methods.
+	HandleOPTIONS bool
+	// HandleHEAD enables dispatching HEAD requests to the GET handler of any registered path that has no
+	// HEAD handler of its own. The response body the GET handler writes is discarded, but the response headers,
+	// e.g. Content-Type and Content-Length, are those of a GET request.
+	HandleHEAD bool
+	// GlobalOPTIONS, if specified, serves the automatic responses to OPTIONS requests, e.g. for CORS preflight.
+	// The Allow header is set before it is invoked.
+	GlobalOPTIONS http.Handler
+	// PathPolicy determines how request paths that differ from their canonical form are treated.
+	PathPolicy PathPolicy
+	// CasePolicy determines how the case of static path segments is treated.
+	CasePolicy CasePolicy
+	// VersionPrefix enables resolving the API version of requests from a path prefix naming a declared version
+	// after VersionPathPrefix, e.g. /v2/users. The prefix is stripped from the request path before routing.
+	// See Router.Version.
+	VersionPrefix bool
+	// VersionHeader, if specified, names the request header to resolve the API version of requests from,
+	// e.g. X-API-Version.
+	VersionHeader string
+	// VersionMediaType enables resolving the API version of requests from the media types of their Accept header,
+	// either by a version parameter, e.g. application/json; version=2, or by a vendor subtype,
+	// e.g. application/vnd.acme.v2+json. Such media ranges also accept the media types Route records produce
+	// without the version, e.g. application/json. See RouteBuilder.Produces.
+	VersionMediaType bool
+	// AllowOverride permits a Route record to replace a registered Route record with the same path and method,
+	// rather than failing registration with ErrDuplicateRoute.
+	AllowOverride bool
 	middlewares   middlewares
-	isFileHandler bool
+	names         map[string]*Route
+	// hosts holds the Router's hosts in order of registration.
+	hosts []*host
+	// versions holds the Router's API versions in order of declaration.
+	versions []*Version
+	mu       sync.Mutex
 }
 
 var (
-	cachedRoute                    = &Route{}
 	DefaultNotFoundHandler         = http.NotFoundHandler
 	DefaultMethodNotAllowedHandler = func() http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		})
 	}
+	DefaultOPTIONSHandler = func() http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+	DefaultNoMatchHandler = func() http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, ErrNoMatch.Error(), http.StatusBadRequest)
+		})
+	}
+	DefaultNotAcceptableHandler = func() http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotAcceptable)
+		})
+	}
 )
 
 // NewRouter constructs and returns a pointer to a new Router.
 func NewRouter() *Router {
 	return &Router{
-		trie: newTrie(),
+		trie:          newTrie(),
+		HandleOPTIONS: true,
 	}
 }
 
-// Use adds middlewares to the current Route record.
-func (r *Router) Use(mws ...middleware) *Router {
-	nm := newMiddlewares(mws)
-	cachedRoute.middlewares = nm
-	return r
+// Route begins a new Route record bound to the Router.
+func (r *Router) Route() *RouteBuilder {
+	return newRouteBuilder(r)
+}
+
+// Use begins a new Route record and adds middlewares to it, e.g. r.Use(auth).WithMethods(...).Handler(...).Register().
+// The middlewares apply only to that Route record; see UseGlobal for middlewares that apply to every request.
+func (r *Router) Use(mws ...middleware) *RouteBuilder {
+	return r.Route().Use(mws...)
 }
 
-// WithMethods appends user-specified HTTP methods to the current Route record.
-func (r *Router) WithMethods(methods ...string) *Router {
-	cachedRoute.methods = append(cachedRoute.methods, methods...)
+// UseGlobal adds middlewares to the Router. Router middlewares apply to every request the Router serves, including
+// those served by the NotFoundHandler and MethodNotAllowedHandler, and run before any middlewares added to a Group
+// or an individual Route record. Router middlewares added after registration still apply.
+//
+// UseGlobal supersedes any use of Use to add middlewares to the Router: Use adds middlewares to a new Route record
+// only, as it always has.
+func (r *Router) UseGlobal(mws ...middleware) *Router {
+	r.middlewares = append(r.middlewares, newMiddlewares(mws)...)
 
 	return r
 }
 
-// Handler adds a path and handler to the current Route record.
-func (r *Router) Handler(path string, handler http.Handler) *Router {
-	cachedRoute.path = path
-	cachedRoute.handler = handler
+// WithMethods begins a new Route record and appends user-specified HTTP methods to it.
+func (r *Router) WithMethods(methods ...string) *RouteBuilder {
+	return r.Route().WithMethods(methods...)
+}
 
-	return r
+// Handler begins a new Route record and adds a path and handler to it.
+func (r *Router) Handler(path string, handler http.Handler) *RouteBuilder {
+	return r.Route().Handler(path, handler)
 }
 
-// FileHandler registers a route handler as a file server, serving the directory qualified as `path`.
-// This method effectively replaces the `Handler()` stage of the route pipeline.
-// @todo allow usage of custom NotFoundHandler
-func (r *Router) FileHandler(path string, root http.FileSystem) *Router {
-	fileServer := http.FileServer(root)
+// FileHandler begins a new Route record and registers its handler as a file server.
+// See RouteBuilder.FileHandler.
+func (r *Router) FileHandler(path string, root http.FileSystem) *RouteBuilder {
+	return r.Route().FileHandler(path, root)
+}
 
-	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		fileServer.ServeHTTP(w, req)
-	})
+// Mount mounts a handler at the path qualified by `prefix`. The handler serves every request under the prefix
+// that no Route record matches more specifically. The prefix is stripped from the request path before the request
+// is delegated to the handler; any path parameters captured by the prefix remain available via GetParam.
+// The handler may be any http.Handler, including another Router.
+// Mount returns a *RouteError if the handler is nil, the prefix is invalid, or a handler is already mounted there.
+func (r *Router) Mount(prefix string, handler http.Handler) error {
+	return r.mount("", joinPath("", prefix), handler, nil)
+}
+
+// mount inserts a mounted handler into the trie of the Router's host with the pattern `host`, or into the Router's
+// default trie if `host` is empty.
+func (r *Router) mount(host string, path string, handler http.Handler, mws middlewares) error {
+	route := &Route{
+		path:        path,
+		handler:     handler,
+		middlewares: mws,
+		host:        host,
+	}
+
+	if handler == nil {
+		return newRouteError(route, ErrNoHandler)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, err := r.trieFor(route.host)
+	if err != nil {
+		return newRouteError(route, err)
+	}
+
+	t.allowOverride = r.AllowOverride
+	if err := t.insertMount(route); err != nil {
+		return newRouteError(route, err)
+	}
+
+	return nil
+}
+
+// register inserts a Route record into the trie of the Router's host it is registered for, or into the Router's
+// default trie.
+func (r *Router) register(route *Route) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.names[route.name]; ok && route.name != "" && !r.AllowOverride {
+		return newRouteError(route, fmt.Errorf("%w: %s", ErrDuplicateName, route.name))
+	}
+
+	t, err := r.trieFor(route.host)
+	if err != nil {
+		return newRouteError(route, err)
+	}
+
+	t.allowOverride = r.AllowOverride
+	if err := t.insertRoute(route); err != nil {
+		return newRouteError(route, err)
+	}
+
+	if route.name != "" {
+		if r.names == nil {
+			r.names = make(map[string]*Route)
+		}
+
+		r.names[route.name] = route
+	}
 
-	cachedRoute.isFileHandler = true
-	return r.Handler(path, handler).WithMethods(http.MethodGet)
+	return nil
 }
 
-// Register registers the current Route record. This method must be invoked to register the Route.
-func (r *Router) Register() {
-	if len(cachedRoute.methods) == 0 {
-		panic("Cannot register a route handler with no specified HTTP methods.")
+// URL builds the URL path of the Route record named `name`. Path parameter values are given as key-value pairs,
+// e.g. URL("user.show", "id", "42"). Each value must match its segment's pattern; values are path-escaped.
+// URL returns ErrUnknownRoute if no Route record is named `name`, ErrMissingParameter if a path parameter of the
+// route has no value, and ErrInvalidParameter if a value does not match its pattern.
+// If VersionPrefix is enabled, the path of a Route record registered against an API version is prefixed with it,
+// e.g. /v2/users/42, such that the URL reaches that Route record.
+func (r *Router) URL(name string, params ...string) (string, error) {
+	r.mu.Lock()
+	route, ok := r.names[name]
+	r.mu.Unlock()
+
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownRoute, name)
+	}
+
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("%w: odd number of key-value pairs for route %s", ErrMissingParameter, name)
 	}
 
-	if cachedRoute.path == "" || cachedRoute.handler == nil {
-		panic("Cannot register a route handler with no specified path or handler.")
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		values[params[i]] = params[i+1]
 	}
 
-	if cachedRoute.isFileHandler && len(cachedRoute.methods) > 1 {
-		panic("Cannot register a file route handler with HTTP methods other than GET.")
+	path, err := buildPath(route.path, values)
+	if err != nil || !r.VersionPrefix || route.version == nil {
+		return path, err
 	}
 
-	r.trie.insert(cachedRoute.methods, cachedRoute.path, cachedRoute.handler, cachedRoute.middlewares)
-	cachedRoute = &Route{}
+	return withPrefix(PathDelimiter+VersionPathPrefix+route.version.name, path), nil
 }
 
 // ServeHTTP routes an HTTP request to the appropriate Route record handler.
+// Router-level middlewares wrap every handler invoked here, including the NotFoundHandler and MethodNotAllowedHandler.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	method := req.Method
-	path := req.URL.Path
+	path := cleanPath(req.URL.Path)
+
+	var handler http.Handler
+
+	t, hostParams := r.matchHost(req.Host)
+
+	var result *result
+
+	version, prefix, err := r.resolveVersion(w, req, path)
+	if version != nil {
+		version.setHeaders(w.Header())
+		path = cleanPath(strings.TrimPrefix(path, prefix))
+	}
 
-	result, err := r.trie.search(method, path)
-	if err == ErrNotFound {
-		if r.NotFoundHandler == nil {
-			DefaultNotFoundHandler().ServeHTTP(w, req)
-			return
+	if err == nil {
+		result, err = r.search(t, method, path)
+	}
+
+	matchedMethod := method
+
+	// Dispatch HEAD requests to the GET action, if extant, discarding the response body.
+	var head *headResponseWriter
+	if err == ErrMethodNotAllowed && method == http.MethodHead && r.HandleHEAD && contains(result.allowed, http.MethodGet) {
+		result, err = r.search(t, http.MethodGet, path)
+		matchedMethod = http.MethodGet
+		head = &headResponseWriter{ResponseWriter: w}
+		w = head
+	}
+
+	// Select among the actions registered for the matched path and method per their RequestMatchers and API
+	// versions, then per the media types they produce.
+	var mediaType string
+	if err == nil {
+		result.actions, mediaType, err = r.selectAction(w, req, result.actions, version)
+	}
+
+	switch err {
+	case ErrNotFound:
+		handler = r.notFoundHandler()
+
+	case ErrNoMatch:
+		handler = r.noMatchHandler()
+
+	case ErrNotAcceptable:
+		handler = r.notAcceptableHandler()
+
+	case ErrMethodNotAllowed:
+		allowed := result.allowed
+		if r.HandleHEAD && contains(allowed, http.MethodGet) && !contains(allowed, http.MethodHead) {
+			allowed = append(allowed, http.MethodHead)
+		}
+
+		if r.HandleOPTIONS && !contains(allowed, http.MethodOptions) {
+			allowed = append(allowed, http.MethodOptions)
+		}
+
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+
+		if method == http.MethodOptions && r.HandleOPTIONS {
+			handler = r.optionsHandler()
+		} else {
+			handler = r.methodNotAllowedHandler()
+		}
+
+	default:
+		if r.CasePolicy == CaseRedirect && result.path != path {
+			handler = redirectHandler(withPrefix(prefix, result.canonicalPath(result.path)))
+			break
+		}
+
+		if canonical := withPrefix(prefix, result.canonicalPath(path)); canonical != req.URL.Path && r.PathPolicy != PathLenient {
+			if r.PathPolicy == PathStrict {
+				handler = r.notFoundHandler()
+			} else {
+				handler = redirectHandler(canonical)
+			}
+
+			break
+		}
+
+		handler = result.actions.handler
+		// If extant, apply route middlewares.
+		if result.actions.middlewares != nil {
+			handler = result.actions.middlewares.then(result.actions.handler)
+		}
+
+		if result.mounted {
+			req = withPath(req, result.remainder)
+		}
+
+		ctx := req.Context()
+
+		if result.parameters != nil || hostParams != nil {
+			params := append(hostParams, result.actions.rename(result.parameters)...)
+			// Retain the parameters captured by a parent Router this Router is mounted on, if extant.
+			if inherited, ok := ctx.Value(parameterKey).([]*parameter); ok {
+				params = append(append([]*parameter(nil), inherited...), params...)
+			}
+
+			ctx = context.WithValue(ctx, parameterKey, params)
+		}
+
+		ctx = withRouteMatch(ctx, result.actions.route, matchedMethod, result.mounted)
+		if mediaType != "" {
+			ctx = context.WithValue(ctx, mediaTypeKey, mediaType)
+		}
+
+		if version != nil {
+			ctx = context.WithValue(ctx, versionKey, version)
+		}
+
+		req = req.WithContext(ctx)
+	}
+
+	// If extant, apply router middlewares.
+	if r.middlewares != nil {
+		handler = r.middlewares.then(handler)
+	}
+
+	handler.ServeHTTP(w, req)
+
+	if head != nil {
+		head.finish()
+	}
+}
+
+// search searches the given trie of the Router per the Router's CasePolicy.
+func (r *Router) search(t *trie, method string, path string) (*result, error) {
+	if r.CasePolicy == CaseSensitive {
+		return t.search(method, path)
+	}
+
+	return t.searchFold(method, path)
+}
+
+// notFoundHandler returns the Router's NotFoundHandler, or the default if none was specified.
+func (r *Router) notFoundHandler() http.Handler {
+	if r.NotFoundHandler == nil {
+		return DefaultNotFoundHandler()
+	}
+
+	return r.NotFoundHandler
+}
+
+// selectAction selects among the chain of actions headed by `actions` the action to serve the request with: of the
+// actions whose RequestMatchers the request satisfies and that serve the API version `version`, if any, that
+// producing the media type the request prefers.
+// selectAction returns the selected action and media type, if negotiated, or either ErrNoMatch, ErrNotFound or
+// ErrNotAcceptable. If any candidate action declares the media types it produces, the response varies by the
+// Accept header.
+func (r *Router) selectAction(w http.ResponseWriter, req *http.Request, actions *action, version *Version) (*action, string, error) {
+	candidates := actions.candidates(req)
+	if len(candidates) == 0 {
+		return nil, "", ErrNoMatch
+	}
+
+	if version != nil {
+		if candidates = selectVersion(candidates, version); len(candidates) == 0 {
+			return nil, "", ErrNotFound
 		}
-		r.NotFoundHandler.ServeHTTP(w, req)
-		return
 	}
 
-	if err == ErrMethodNotAllowed {
-		if r.MethodNotAllowedHandler == nil {
-			DefaultMethodNotAllowedHandler().ServeHTTP(w, req)
-			return
+	negotiated := false
+	for _, a := range candidates {
+		negotiated = negotiated || len(a.produces) > 0
+	}
+
+	if !negotiated {
+		return candidates[0], "", nil
+	}
+
+	addVary(w.Header(), "Accept")
+
+	ranges := acceptRanges(req)
+	if r.VersionMediaType {
+		ranges = withUnversionedRanges(ranges)
+	}
+
+	return negotiate(candidates, ranges)
+}
+
+// notAcceptableHandler returns the Router's NotAcceptableHandler, or the default if none was specified.
+func (r *Router) notAcceptableHandler() http.Handler {
+	if r.NotAcceptableHandler == nil {
+		return DefaultNotAcceptableHandler()
+	}
+
+	return r.NotAcceptableHandler
+}
+
+// noMatchHandler returns the Router's NoMatchHandler, or the default if none was specified.
+func (r *Router) noMatchHandler() http.Handler {
+	if r.NoMatchHandler == nil {
+		return DefaultNoMatchHandler()
+	}
+
+	return r.NoMatchHandler
+}
+
+// redirectHandler returns a handler that redirects requests to the given decoded path, retaining their query.
+// The path is escaped, such that characters decoded from the request path, e.g. ? or \, cannot alter the meaning of
+// the Location header.
+func redirectHandler(path string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		code := http.StatusPermanentRedirect
+		if req.Method == http.MethodGet || req.Method == http.MethodHead {
+			code = http.StatusMovedPermanently
 		}
-		r.MethodNotAllowedHandler.ServeHTTP(w, req)
+
+		target := (&url.URL{Path: path}).EscapedPath()
+		if req.URL.RawQuery != "" {
+			target += "?" + req.URL.RawQuery
+		}
+
+		http.Redirect(w, req, target, code)
+	})
+}
+
+// optionsHandler returns the Router's GlobalOPTIONS handler, or the default if none was specified.
+func (r *Router) optionsHandler() http.Handler {
+	if r.GlobalOPTIONS == nil {
+		return DefaultOPTIONSHandler()
+	}
+
+	return r.GlobalOPTIONS
+}
+
+// methodNotAllowedHandler returns the Router's MethodNotAllowedHandler, or the default if none was specified.
+func (r *Router) methodNotAllowedHandler() http.Handler {
+	if r.MethodNotAllowedHandler == nil {
+		return DefaultMethodNotAllowedHandler()
+	}
+
+	return r.MethodNotAllowedHandler
+}
+
+// withPath returns a shallow copy of the given request with its URL path replaced by `path`.
+func withPath(req *http.Request, path string) *http.Request {
+	r := new(http.Request)
+	*r = *req
+	r.URL = new(url.URL)
+	*r.URL = *req.URL
+	r.URL.Path = path
+	r.URL.RawPath = ""
+
+	return r
+}
+
+// headResponseWriter is an http.ResponseWriter that discards the response body, for responding to HEAD requests.
+// It defers writing the response header until the handler flushes or returns, such that the header describes the
+// discarded body as it would have for a GET request: its Content-Type, if unset, is detected from the body, and its
+// Content-Length, if unset, is that of the body.
+type headResponseWriter struct {
+	http.ResponseWriter
+	code        int
+	written     int
+	wroteHeader bool
+}
+
+// WriteHeader records the status code, to be written when the handler flushes or returns.
+func (w *headResponseWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+// Write discards the given bytes, reporting them as written.
+func (w *headResponseWriter) Write(b []byte) (int, error) {
+	if w.written == 0 && len(b) > 0 && !w.wroteHeader && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", http.DetectContentType(b))
+	}
+
+	w.written += len(b)
+
+	return len(b), nil
+}
+
+// Flush writes the response header and flushes the underlying http.ResponseWriter, if it supports flushing.
+func (w *headResponseWriter) Flush() {
+	w.writeHeader()
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying http.ResponseWriter, for http.ResponseController.
+func (w *headResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish writes the response header once the handler has returned, with the length of the discarded body.
+func (w *headResponseWriter) finish() {
+	if !w.wroteHeader && w.written > 0 && w.Header().Get("Content-Length") == "" && w.Header().Get("Transfer-Encoding") == "" {
+		w.Header().Set("Content-Length", strconv.Itoa(w.written))
+	}
+
+	w.writeHeader()
+}
+
+// writeHeader writes the response header, if not yet written.
+func (w *headResponseWriter) writeHeader() {
+	if w.wroteHeader {
 		return
 	}
 
-	handler := result.actions.handler
-	// If extant, apply middlewares.
-	if result.actions.middlewares != nil {
-		handler = result.actions.middlewares.then(result.actions.handler)
+	w.wroteHeader = true
+
+	if w.code == 0 {
+		w.code = http.StatusOK
 	}
 
-	if result.parameters != nil {
-		ctx := context.WithValue(req.Context(), parameterKey, result.parameters)
-		req = req.WithContext(ctx)
+	w.ResponseWriter.WriteHeader(w.code)
+}
+
+// addVary adds the given header name to the Vary header, unless already listed.
+func addVary(header http.Header, name string) {
+	for _, value := range header.Values("Vary") {
+		for _, listed := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(listed), name) {
+				return
+			}
+		}
 	}
 
-	handler.ServeHTTP(w, req)
+	header.Add("Vary", name)
+}
+
+// contains determines whether the given slice contains the string `s`.
+func contains(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+
+	return false
 }
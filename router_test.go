@@ -7,7 +7,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
 )
 
 type testCase struct {
@@ -30,7 +33,8 @@ func (mfs *mockFileSystem) Open(name string) (http.File, error) {
 func TestNewRouter(t *testing.T) {
 	actual := NewRouter()
 	expected := &Router{
-		trie: newTrie(),
+		trie:          newTrie(),
+		HandleOPTIONS: true,
 	}
 
 	if !reflect.DeepEqual(actual, expected) {
@@ -287,6 +291,7 @@ func TestCustomNotFoundHandler(t *testing.T) {
 
 func TestCustomMethodNotAllowedHandler(t *testing.T) {
 	r := NewRouter()
+	r.HandleOPTIONS = false
 	r.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		fmt.Fprintf(w, "MethodNotAllowed")
@@ -344,6 +349,356 @@ func TestCustomMethodNotAllowedHandler(t *testing.T) {
 	runHTTPTests(t, r, tests)
 }
 
+func TestAllowHeaderAndOPTIONS(t *testing.T) {
+	type testCase struct {
+		name   string
+		path   string
+		method string
+		code   int
+		allow  string
+		body   string
+	}
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "OK")
+	})
+
+	r := NewRouter()
+	r.WithMethods(http.MethodGet, http.MethodPost).Handler("/foo", testHandler).MustRegister()
+	r.WithMethods(http.MethodGet, http.MethodOptions).Handler("/bar", testHandler).MustRegister()
+	r.WithMethods(http.MethodDelete).Handler("/baz/:id", testHandler).MustRegister()
+
+	cors := NewRouter()
+	cors.GlobalOPTIONS = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Methods", w.Header().Get("Allow"))
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "preflight")
+	})
+	cors.WithMethods(http.MethodPut).Handler("/foo", testHandler).MustRegister()
+
+	manual := NewRouter()
+	manual.HandleOPTIONS = false
+	manual.WithMethods(http.MethodGet).Handler("/foo", testHandler).MustRegister()
+
+	tests := []struct {
+		router *Router
+		testCase
+	}{
+		{r, testCase{name: "MethodNotAllowedAllow", path: "/foo", method: http.MethodPut, code: http.StatusMethodNotAllowed, allow: "GET, POST, OPTIONS"}},
+		{r, testCase{name: "AutomaticOPTIONS", path: "/foo", method: http.MethodOptions, code: http.StatusNoContent, allow: "GET, POST, OPTIONS"}},
+		{r, testCase{name: "AutomaticOPTIONSWithParams", path: "/baz/12", method: http.MethodOptions, code: http.StatusNoContent, allow: "DELETE, OPTIONS"}},
+		{r, testCase{name: "ExplicitOPTIONS", path: "/bar", method: http.MethodOptions, code: http.StatusOK, body: "OK"}},
+		{r, testCase{name: "ExplicitOPTIONSAllow", path: "/bar", method: http.MethodPost, code: http.StatusMethodNotAllowed, allow: "GET, OPTIONS"}},
+		{r, testCase{name: "UnregisteredPath", path: "/qux", method: http.MethodOptions, code: http.StatusNotFound}},
+		{cors, testCase{name: "GlobalOPTIONS", path: "/foo", method: http.MethodOptions, code: http.StatusOK, allow: "PUT, OPTIONS", body: "preflight"}},
+		{manual, testCase{name: "DisabledOPTIONS", path: "/foo", method: http.MethodOptions, code: http.StatusMethodNotAllowed, allow: "GET"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.path, nil)
+			rec := httptest.NewRecorder()
+
+			test.router.ServeHTTP(rec, req)
+
+			if rec.Code != test.code {
+				t.Errorf("expected code %d but got %d\n", test.code, rec.Code)
+			}
+
+			if allow := rec.Header().Get("Allow"); allow != test.allow {
+				t.Errorf("expected Allow header %q but got %q\n", test.allow, allow)
+			}
+
+			if test.body != "" && rec.Body.String() != test.body {
+				t.Errorf("expected body %s but got %s\n", test.body, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleHEAD(t *testing.T) {
+	getHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Method", r.Method)
+		if _, ok := w.(http.Flusher); ok {
+			w.Header().Set("X-Flusher", "true")
+		}
+		fmt.Fprintf(w, "GET body")
+	})
+
+	headHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Method", "explicit")
+	})
+
+	r := NewRouter()
+	r.HandleHEAD = true
+	r.WithMethods(http.MethodGet).Handler("/foo/:id", getHandler).MustRegister()
+	r.WithMethods(http.MethodGet).Handler("/bar", getHandler).MustRegister()
+	r.WithMethods(http.MethodHead).Handler("/bar", headHandler).MustRegister()
+	r.WithMethods(http.MethodPost).Handler("/baz", getHandler).MustRegister()
+
+	disabled := NewRouter()
+	disabled.WithMethods(http.MethodGet).Handler("/foo", getHandler).MustRegister()
+
+	type testCase struct {
+		name      string
+		router    *Router
+		path      string
+		method    string
+		code      int
+		xMethod   string
+		allow     string
+		headers   map[string]string
+		emptyBody bool
+	}
+
+	// The headers describing the GET handler's body must survive HEAD requests, whatever the http.ResponseWriter.
+	headHeaders := map[string]string{"Content-Type": "text/plain; charset=utf-8", "Content-Length": "8", "X-Flusher": "true"}
+
+	tests := []testCase{
+		{name: "ImplicitHEAD", router: r, path: "/foo/1", method: http.MethodHead, code: http.StatusOK, xMethod: http.MethodHead, headers: headHeaders, emptyBody: true},
+		{name: "GETUnaffected", router: r, path: "/foo/1", method: http.MethodGet, code: http.StatusOK, xMethod: http.MethodGet, headers: map[string]string{"X-Flusher": "true"}},
+		{name: "ExplicitHEAD", router: r, path: "/bar", method: http.MethodHead, code: http.StatusOK, xMethod: "explicit", emptyBody: true},
+		{name: "NoGET", router: r, path: "/baz", method: http.MethodHead, code: http.StatusMethodNotAllowed, allow: "POST, OPTIONS", emptyBody: true},
+		{name: "AllowIncludesHEAD", router: r, path: "/foo/1", method: http.MethodPut, code: http.StatusMethodNotAllowed, allow: "GET, HEAD, OPTIONS", emptyBody: true},
+		{name: "Disabled", router: disabled, path: "/foo", method: http.MethodHead, code: http.StatusMethodNotAllowed, allow: "GET, OPTIONS", emptyBody: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.path, nil)
+			rec := httptest.NewRecorder()
+
+			test.router.ServeHTTP(rec, req)
+
+			if rec.Code != test.code {
+				t.Errorf("expected code %d but got %d\n", test.code, rec.Code)
+			}
+
+			if xMethod := rec.Header().Get("X-Method"); xMethod != test.xMethod {
+				t.Errorf("expected handler to observe %q but got %q\n", test.xMethod, xMethod)
+			}
+
+			if allow := rec.Header().Get("Allow"); allow != test.allow {
+				t.Errorf("expected Allow header %q but got %q\n", test.allow, allow)
+			}
+
+			for key, expected := range test.headers {
+				if actual := rec.Header().Get(key); actual != expected {
+					t.Errorf("expected %s header %q but got %q\n", key, expected, actual)
+				}
+			}
+
+			if test.emptyBody && rec.Body.Len() != 0 {
+				t.Errorf("expected an empty body but got %s\n", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleHEADFlush(t *testing.T) {
+	r := NewRouter()
+	r.HandleHEAD = true
+	r.WithMethods(http.MethodGet).Handler("/events", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprintf(w, "data: 1\n\n")
+		w.(http.Flusher).Flush()
+		fmt.Fprintf(w, "data: 2\n\n")
+	})).MustRegister()
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/events", nil))
+
+	if rec.Code != http.StatusAccepted || !rec.Flushed {
+		t.Errorf("expected a flushed %d response but got %d (flushed: %v)", http.StatusAccepted, rec.Code, rec.Flushed)
+	}
+
+	// The header was written by the flush, before the length of the body was known.
+	if length := rec.Header().Get("Content-Length"); length != "" {
+		t.Errorf("expected no Content-Length header but got %s", length)
+	}
+
+	if rec.Body.Len() != 0 {
+		t.Errorf("expected an empty body but got %s", rec.Body.String())
+	}
+}
+
+func TestPathPolicy(t *testing.T) {
+	type testCase struct {
+		name     string
+		policy   PathPolicy
+		path     string
+		method   string
+		code     int
+		location string
+	}
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	newPolicyRouter := func(policy PathPolicy) *Router {
+		r := NewRouter()
+		r.PathPolicy = policy
+
+		r.WithMethods(http.MethodGet, http.MethodPost).Handler("/foo", testHandler).MustRegister()
+		r.WithMethods(http.MethodGet).Handler("/bar/", testHandler).MustRegister()
+		r.WithMethods(http.MethodGet).Handler("/", testHandler).MustRegister()
+		r.WithMethods(http.MethodGet).Handler("/files/*filepath", testHandler).MustRegister()
+
+		return r
+	}
+
+	routers := map[PathPolicy]*Router{
+		PathLenient:  newPolicyRouter(PathLenient),
+		PathStrict:   newPolicyRouter(PathStrict),
+		PathRedirect: newPolicyRouter(PathRedirect),
+	}
+
+	tests := []testCase{
+		{name: "LenientCanonical", policy: PathLenient, path: "/foo", method: http.MethodGet, code: http.StatusOK},
+		{name: "LenientTrailingSlash", policy: PathLenient, path: "/foo/", method: http.MethodGet, code: http.StatusOK},
+		{name: "LenientMissingTrailingSlash", policy: PathLenient, path: "/bar", method: http.MethodGet, code: http.StatusOK},
+		{name: "LenientRedundantDelimiters", policy: PathLenient, path: "//foo", method: http.MethodGet, code: http.StatusOK},
+		{name: "LenientDotSegments", policy: PathLenient, path: "/bar/../foo", method: http.MethodGet, code: http.StatusOK},
+
+		{name: "StrictCanonical", policy: PathStrict, path: "/foo", method: http.MethodGet, code: http.StatusOK},
+		{name: "StrictCanonicalTrailingSlash", policy: PathStrict, path: "/bar/", method: http.MethodGet, code: http.StatusOK},
+		{name: "StrictRoot", policy: PathStrict, path: "/", method: http.MethodGet, code: http.StatusOK},
+		{name: "StrictTrailingSlash", policy: PathStrict, path: "/foo/", method: http.MethodGet, code: http.StatusNotFound},
+		{name: "StrictMissingTrailingSlash", policy: PathStrict, path: "/bar", method: http.MethodGet, code: http.StatusNotFound},
+		{name: "StrictRedundantDelimiters", policy: PathStrict, path: "//foo", method: http.MethodGet, code: http.StatusNotFound},
+		{name: "StrictDotSegments", policy: PathStrict, path: "/bar/../foo", method: http.MethodGet, code: http.StatusNotFound},
+		{name: "StrictCatchAllTrailingSlash", policy: PathStrict, path: "/files/css/", method: http.MethodGet, code: http.StatusOK},
+
+		{name: "RedirectCanonical", policy: PathRedirect, path: "/foo", method: http.MethodGet, code: http.StatusOK},
+		{name: "RedirectTrailingSlash", policy: PathRedirect, path: "/foo/", method: http.MethodGet, code: http.StatusMovedPermanently, location: "/foo"},
+		{name: "RedirectMissingTrailingSlash", policy: PathRedirect, path: "/bar", method: http.MethodGet, code: http.StatusMovedPermanently, location: "/bar/"},
+		{name: "RedirectDotSegments", policy: PathRedirect, path: "/bar/./../foo", method: http.MethodGet, code: http.StatusMovedPermanently, location: "/foo"},
+		{name: "RedirectRedundantDelimiters", policy: PathRedirect, path: "/files//css//app.css", method: http.MethodGet, code: http.StatusMovedPermanently, location: "/files/css/app.css"},
+		{name: "RedirectQuery", policy: PathRedirect, path: "/foo/?q=1", method: http.MethodGet, code: http.StatusMovedPermanently, location: "/foo?q=1"},
+		{name: "RedirectNonGET", policy: PathRedirect, path: "/foo/", method: http.MethodPost, code: http.StatusPermanentRedirect, location: "/foo"},
+		{name: "RedirectNotFound", policy: PathRedirect, path: "/baz/", method: http.MethodGet, code: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.path, nil)
+			rec := httptest.NewRecorder()
+
+			routers[test.policy].ServeHTTP(rec, req)
+
+			if rec.Code != test.code {
+				t.Errorf("expected code %d but got %d\n", test.code, rec.Code)
+			}
+
+			if location := rec.Header().Get("Location"); location != test.location {
+				t.Errorf("expected Location %q but got %q\n", test.location, location)
+			}
+		})
+	}
+}
+
+func TestRedirectEscaping(t *testing.T) {
+	type testCase struct {
+		name     string
+		path     string
+		location string
+	}
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	r := NewRouter()
+	r.PathPolicy = PathRedirect
+	r.CasePolicy = CaseRedirect
+	r.WithMethods(http.MethodGet).Handler("/users/:id", testHandler).MustRegister()
+	r.WithMethods(http.MethodGet).Handler("/:page", testHandler).MustRegister()
+
+	tests := []testCase{
+		{name: "Backslash", path: "/%5Cevil.example/", location: "/%5Cevil.example"},
+		{name: "QuestionMark", path: "/users/hello%3Fx/", location: "/users/hello%3Fx"},
+		{name: "Space", path: "/users/a%20b/", location: "/users/a%20b"},
+		{name: "QuestionMarkWithQuery", path: "/users/hello%3Fx/?q=1", location: "/users/hello%3Fx?q=1"},
+		{name: "CaseRedirectQuestionMark", path: "/Users/hello%3Fx", location: "/users/hello%3Fx"},
+		{name: "CaseRedirectBackslash", path: "/USERS/%5Cevil.example", location: "/users/%5Cevil.example"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+			if rec.Code != http.StatusMovedPermanently {
+				t.Errorf("expected code %d but got %d\n", http.StatusMovedPermanently, rec.Code)
+			}
+
+			if location := rec.Header().Get("Location"); location != test.location {
+				t.Errorf("expected Location %q but got %q\n", test.location, location)
+			}
+		})
+	}
+}
+
+func TestCasePolicy(t *testing.T) {
+	type testCase struct {
+		name     string
+		router   *Router
+		path     string
+		method   string
+		code     int
+		body     string
+		location string
+	}
+
+	newCaseRouter := func(policy CasePolicy, pathPolicy PathPolicy) *Router {
+		r := NewRouter()
+		r.CasePolicy = policy
+		r.PathPolicy = pathPolicy
+
+		r.WithMethods(http.MethodGet, http.MethodPost).Handler("/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "user %s", GetParam(r.Context(), "id"))
+		})).MustRegister()
+
+		return r
+	}
+
+	sensitive := newCaseRouter(CaseSensitive, PathLenient)
+	insensitive := newCaseRouter(CaseInsensitive, PathLenient)
+	redirect := newCaseRouter(CaseRedirect, PathLenient)
+	strictRedirect := newCaseRouter(CaseRedirect, PathStrict)
+
+	tests := []testCase{
+		{name: "SensitiveMatch", router: sensitive, path: "/users/AbC", method: http.MethodGet, code: http.StatusOK, body: "user AbC"},
+		{name: "SensitiveMismatch", router: sensitive, path: "/Users/AbC", method: http.MethodGet, code: http.StatusNotFound},
+		{name: "InsensitiveMatch", router: insensitive, path: "/USERS/AbC", method: http.MethodGet, code: http.StatusOK, body: "user AbC"},
+		{name: "RedirectMatch", router: redirect, path: "/users/AbC", method: http.MethodGet, code: http.StatusOK, body: "user AbC"},
+		{name: "Redirect", router: redirect, path: "/Users/AbC?q=1", method: http.MethodGet, code: http.StatusMovedPermanently, location: "/users/AbC?q=1"},
+		{name: "RedirectNonGET", router: redirect, path: "/Users/AbC", method: http.MethodPost, code: http.StatusPermanentRedirect, location: "/users/AbC"},
+		{name: "RedirectCanonicalPath", router: redirect, path: "//Users/AbC/", method: http.MethodGet, code: http.StatusMovedPermanently, location: "/users/AbC"},
+		{name: "StrictRedirect", router: strictRedirect, path: "/Users/AbC", method: http.MethodGet, code: http.StatusMovedPermanently, location: "/users/AbC"},
+		{name: "StrictRedirectCanonicalCase", router: strictRedirect, path: "/users/AbC/", method: http.MethodGet, code: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.path, nil)
+			rec := httptest.NewRecorder()
+
+			test.router.ServeHTTP(rec, req)
+
+			if rec.Code != test.code {
+				t.Errorf("expected code %d but got %d\n", test.code, rec.Code)
+			}
+
+			if test.body != "" && rec.Body.String() != test.body {
+				t.Errorf("expected body %s but got %s\n", test.body, rec.Body.String())
+			}
+
+			if location := rec.Header().Get("Location"); location != test.location {
+				t.Errorf("expected Location %q but got %q\n", test.location, location)
+			}
+		})
+	}
+}
+
 func TestFileHandler(t *testing.T) {
 	r := NewRouter()
 	mfs := &mockFileSystem{}
@@ -362,6 +717,26 @@ func TestFileHandler(t *testing.T) {
 	}
 }
 
+func TestFileHandlerDirectoryTree(t *testing.T) {
+	fs := fstest.MapFS{
+		"index.html":  {Data: []byte("index")},
+		"css/app.css": {Data: []byte("body {}")},
+	}
+
+	r := NewRouter()
+	r.FileHandler("/static", http.FS(fs)).Register()
+	r.FileHandler("/assets/*asset", http.FS(fs)).Register()
+
+	tests := []testCase{
+		{name: "Index", path: "/static/", method: http.MethodGet, code: http.StatusOK, body: "index"},
+		{name: "NestedFile", path: "/static/css/app.css", method: http.MethodGet, code: http.StatusOK, body: "body {}"},
+		{name: "NamedCatchAll", path: "/assets/css/app.css", method: http.MethodGet, code: http.StatusOK, body: "body {}"},
+		{name: "MissingFile", path: "/static/css/missing.css", method: http.MethodGet, code: http.StatusNotFound},
+	}
+
+	runHTTPTests(t, r, tests)
+}
+
 func TestFileHandlerInvariantViolation(t *testing.T) {
 	r := NewRouter()
 	mfs := &mockFileSystem{}
@@ -372,7 +747,303 @@ func TestFileHandlerInvariantViolation(t *testing.T) {
 		}
 	}()
 
-	r.FileHandler("/", mfs).WithMethods(http.MethodPost).Register()
+	r.FileHandler("/", mfs).WithMethods(http.MethodPost).MustRegister()
+}
+
+func TestRegisterErrors(t *testing.T) {
+	type testCase struct {
+		name     string
+		builder  *RouteBuilder
+		expected error
+	}
+
+	r := NewRouter()
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	r.WithMethods(http.MethodGet).Handler("/foo", testHandler).MustRegister()
+
+	tests := []testCase{
+		{name: "NoMethods", builder: r.Handler("/bar", testHandler), expected: ErrNoMethods},
+		{name: "NoPath", builder: r.WithMethods(http.MethodGet).Handler("", testHandler), expected: ErrNoPath},
+		{name: "NoHandler", builder: r.WithMethods(http.MethodGet).Handler("/bar", nil), expected: ErrNoHandler},
+		{name: "FileHandlerMethods", builder: r.FileHandler("/files", &mockFileSystem{}).WithMethods(http.MethodPost), expected: ErrFileHandlerMethods},
+		{name: "InvalidPattern", builder: r.WithMethods(http.MethodGet).Handler("/bar/*rest/baz", testHandler), expected: ErrInvalidPattern},
+		{name: "DuplicateRoute", builder: r.WithMethods(http.MethodGet).Handler("/foo", testHandler), expected: ErrDuplicateRoute},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.builder.Register()
+			if !errors.Is(err, test.expected) {
+				t.Errorf("expected error %v but got %v", test.expected, err)
+			}
+
+			var routeErr *RouteError
+			if !errors.As(err, &routeErr) {
+				t.Errorf("expected a *RouteError but got %T", err)
+			}
+
+			if strings.Contains(err.Error(), "  ") {
+				t.Errorf("unexpected message %q", err.Error())
+			}
+		})
+	}
+
+	t.Run("MountErrors", func(t *testing.T) {
+		err := r.Mount("/mount", nil)
+		if !errors.Is(err, ErrNoHandler) {
+			t.Errorf("expected error %v but got %v", ErrNoHandler, err)
+		}
+
+		if expected := "cannot register route /mount: " + ErrNoHandler.Error(); err.Error() != expected {
+			t.Errorf("expected message %q but got %q", expected, err.Error())
+		}
+
+		if err := r.Mount("/mount", testHandler); err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+
+		if err := r.Mount("/mount", testHandler); !errors.Is(err, ErrDuplicateRoute) {
+			t.Errorf("expected error %v but got %v", ErrDuplicateRoute, err)
+		}
+	})
+}
+
+func TestRouteBuilderIsolation(t *testing.T) {
+	r1 := NewRouter()
+	r2 := NewRouter()
+
+	// Interleave the construction of two routes across two routers.
+	rb1 := r1.WithMethods(http.MethodGet)
+	rb2 := r2.WithMethods(http.MethodPost)
+
+	rb1.Handler("/one", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "one")
+	}))
+	rb2.Handler("/two", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "two")
+	}))
+
+	rb2.Register()
+	rb1.Register()
+
+	runHTTPTests(t, r1, []testCase{
+		{name: "FirstRouterRoute", path: "/one", method: http.MethodGet, code: http.StatusOK, body: "one"},
+		{name: "FirstRouterForeignRoute", path: "/two", method: http.MethodPost, code: http.StatusNotFound},
+	})
+
+	runHTTPTests(t, r2, []testCase{
+		{name: "SecondRouterRoute", path: "/two", method: http.MethodPost, code: http.StatusOK, body: "two"},
+		{name: "SecondRouterForeignRoute", path: "/one", method: http.MethodGet, code: http.StatusNotFound},
+	})
+}
+
+func TestConcurrentRegistration(t *testing.T) {
+	r := NewRouter()
+	n := 50
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			body := fmt.Sprintf("route%d", i)
+			r.Route().WithMethods(http.MethodGet).Handler("/"+body, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, body)
+			})).Register()
+		}(i)
+	}
+	wg.Wait()
+
+	var tests []testCase
+	for i := 0; i < n; i++ {
+		body := fmt.Sprintf("route%d", i)
+		tests = append(tests, testCase{name: body, path: "/" + body, method: http.MethodGet, code: http.StatusOK, body: body})
+	}
+
+	runHTTPTests(t, r, tests)
+}
+
+func TestMount(t *testing.T) {
+	billing := NewRouter()
+	billing.WithMethods(http.MethodGet).Handler("/invoices/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s invoice %s at %s", GetParam(r.Context(), "tenant"), GetParam(r.Context(), "id"), r.URL.Path)
+	})).Register()
+
+	r := NewRouter()
+	r.Mount("/tenants/:tenant/billing", billing)
+	r.Mount("/echo", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s", r.Method, r.URL.Path)
+	}))
+
+	r.WithMethods(http.MethodGet).Handler("/echo/override", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "override")
+	})).Register()
+
+	r.Group("/group", func(g *Group) {
+		g.Use(first).Mount("/echo", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "%s\n", r.URL.Path)
+		}))
+	})
+
+	tests := []testCase{
+		{
+			name:   "MountedRouter",
+			path:   "/tenants/acme/billing/invoices/12",
+			method: http.MethodGet,
+			code:   http.StatusOK,
+			body:   "acme invoice 12 at /invoices/12",
+		},
+		{
+			name:   "MountedRouterNotFound",
+			path:   "/tenants/acme/billing/receipts/12",
+			method: http.MethodGet,
+			code:   http.StatusNotFound,
+		},
+		{
+			name:   "MountedHandler",
+			path:   "/echo/a/b/",
+			method: http.MethodPost,
+			code:   http.StatusOK,
+			body:   "POST /a/b/",
+		},
+		{
+			name:   "MountedHandlerRoot",
+			path:   "/echo",
+			method: http.MethodGet,
+			code:   http.StatusOK,
+			body:   "GET /",
+		},
+		{
+			name:   "MountedHandlerOverride",
+			path:   "/echo/override",
+			method: http.MethodGet,
+			code:   http.StatusOK,
+			body:   "override",
+		},
+		{
+			name:   "MountedHandlerOverrideAltMethod",
+			path:   "/echo/override",
+			method: http.MethodPost,
+			code:   http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "MountedHandlerBeneathOverride",
+			path:   "/echo/override/more",
+			method: http.MethodGet,
+			code:   http.StatusOK,
+			body:   "GET /override/more",
+		},
+		{
+			name:   "GroupMountedHandler",
+			path:   "/group/echo/x",
+			method: http.MethodGet,
+			code:   http.StatusOK,
+			body:   "first: before\n/x\nfirst: after\n",
+		},
+		{
+			name:   "OutsideMount",
+			path:   "/tenants/acme",
+			method: http.MethodGet,
+			code:   http.StatusNotFound,
+		},
+	}
+
+	runHTTPTests(t, r, tests)
+}
+
+func TestAllowOverride(t *testing.T) {
+	r := NewRouter()
+	r.AllowOverride = true
+
+	r.WithMethods(http.MethodGet, http.MethodPost).Handler("/foo", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "original")
+	})).MustRegister()
+
+	r.WithMethods(http.MethodGet).Handler("/foo", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "override")
+	})).MustRegister()
+
+	// Overriding does not extend to ambiguous routes.
+	if err := r.WithMethods(http.MethodGet).Handler("/:bar", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).Register(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if err := r.WithMethods(http.MethodGet).Handler("/:baz", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).Register(); !errors.Is(err, ErrAmbiguousRoute) {
+		t.Errorf("expected error %v but got %v", ErrAmbiguousRoute, err)
+	}
+
+	tests := []testCase{
+		{name: "OverriddenMethod", path: "/foo", method: http.MethodGet, code: http.StatusOK, body: "override"},
+		{name: "RetainedMethod", path: "/foo", method: http.MethodPost, code: http.StatusOK, body: "original"},
+	}
+
+	runHTTPTests(t, r, tests)
+}
+
+func TestURL(t *testing.T) {
+	type testCase struct {
+		name     string
+		route    string
+		params   []string
+		expected string
+		err      error
+	}
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	r := NewRouter()
+	r.WithMethods(http.MethodGet).Handler("/users/:id[^\\d+$]", testHandler).Name("user.show").MustRegister()
+	r.Group("/api", func(g *Group) {
+		g.WithMethods(http.MethodGet).Handler("/posts/:slug/comments/:id", testHandler).Name("comment.show").MustRegister()
+	})
+	r.VersionPrefix = true
+	r.Version("1", func(g *Group) {
+		g.WithMethods(http.MethodGet).Handler("/a/:id", testHandler).Name("a.v1").MustRegister()
+		g.WithMethods(http.MethodGet).Handler("/", testHandler).Name("root.v1").MustRegister()
+	})
+	r.Version("2", func(g *Group) {})
+
+	tests := []testCase{
+		{name: "NamedRoute", route: "user.show", params: []string{"id", "42"}, expected: "/users/42"},
+		{name: "GroupNamedRoute", route: "comment.show", params: []string{"slug", "hello", "id", "1"}, expected: "/api/posts/hello/comments/1"},
+		{name: "VersionedRoute", route: "a.v1", params: []string{"id", "1"}, expected: "/v1/a/1"},
+		{name: "VersionedRoot", route: "root.v1", expected: "/v1"},
+		{name: "UnknownRoute", route: "user.edit", err: ErrUnknownRoute},
+		{name: "MissingParameter", route: "comment.show", params: []string{"slug", "hello"}, err: ErrMissingParameter},
+		{name: "OddParameters", route: "user.show", params: []string{"id"}, err: ErrMissingParameter},
+		{name: "InvalidParameter", route: "user.show", params: []string{"id", "alice"}, err: ErrInvalidParameter},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := r.URL(test.route, test.params...)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected error %v but got %v", test.err, err)
+			}
+
+			if actual != test.expected {
+				t.Errorf("expected %s but got %s\n", test.expected, actual)
+			}
+		})
+	}
+
+	t.Run("DuplicateName", func(t *testing.T) {
+		err := r.WithMethods(http.MethodGet).Handler("/people/:id", testHandler).Name("user.show").Register()
+		if !errors.Is(err, ErrDuplicateName) {
+			t.Errorf("expected error %v but got %v", ErrDuplicateName, err)
+		}
+
+		// A route that fails to register must not be reachable.
+		req := httptest.NewRequest(http.MethodGet, "/people/1", nil)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected code %d but got %d", http.StatusNotFound, rec.Code)
+		}
+	})
 }
 
 func runHTTPTests(t *testing.T, r *Router, tests []testCase) {
@@ -0,0 +1,170 @@
+package turnpike
+
+import (
+	"sort"
+)
+
+// RouteInfo describes a Route record registered with a Router.
+type RouteInfo struct {
+	// Pattern holds the path pattern the Route record was registered with, e.g. /users/:id[^\d+$].
+	Pattern string
+	// Host holds the host pattern the Route record was registered for, if any. See Router.Host.
+	Host string
+	// Methods holds the HTTP methods the Route record serves, in lexical order. A mounted handler serves every
+	// HTTP method; its Methods is empty.
+	Methods []string
+	// Params holds the keys of the path parameters and catch-all segment of the pattern, in path order.
+	Params []string
+	// Constraints holds the regex pattern constraining each path parameter, keyed by parameter key.
+	// Unconstrained path parameters are omitted.
+	Constraints map[string]string
+	// Middlewares holds the number of middlewares of the Route record, including those of its Group.
+	// Router middlewares are not counted.
+	Middlewares int
+	// Matchers holds the number of RequestMatchers of the Route record.
+	Matchers int
+	// Produces holds the media types the Route record produces, if declared.
+	Produces []string
+	// Version holds the name of the API version the Route record is registered against, if any.
+	Version string
+	// Name holds the name of the Route record, if extant.
+	Name string
+	// IsFileHandler indicates the Route record serves files via FileHandler.
+	IsFileHandler bool
+	// IsMount indicates the Route record is a mounted handler.
+	IsMount bool
+}
+
+// Walk invokes `fn` for each Route record registered with the Router. Route records registered without a host are
+// visited first, then those of each host in order of registration. Within each, Route records are visited
+// depth-first in order of match precedence: static path segments in lexical order, then parameters, then catch-all
+// segments.
+// A Route record registered for several HTTP methods is visited once. Walk stops at, and returns, the first error
+// `fn` returns.
+func (r *Router) Walk(fn func(RouteInfo) error) error {
+	r.mu.Lock()
+	var infos []RouteInfo
+	r.trie.root.collect(&infos)
+	for _, h := range r.hosts {
+		h.trie.root.collect(&infos)
+	}
+	r.mu.Unlock()
+
+	for _, info := range infos {
+		if err := fn(info); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Routes returns a RouteInfo for each Route record registered with the Router, in the order Walk visits them.
+func (r *Router) Routes() []RouteInfo {
+	var infos []RouteInfo
+
+	r.Walk(func(info RouteInfo) error {
+		infos = append(infos, info)
+		return nil
+	})
+
+	return infos
+}
+
+// collect appends a RouteInfo for each Route record registered in the subtree rooted at the node.
+func (n *node) collect(infos *[]RouteInfo) {
+	// Group the node's HTTP methods by the Route record that registered them.
+	var routes []*Route
+	methods := make(map[*Route][]string)
+
+	for _, method := range n.allowed() {
+		for a := n.actions[method]; a != nil; a = a.next {
+			if _, ok := methods[a.route]; !ok {
+				routes = append(routes, a.route)
+			}
+
+			methods[a.route] = append(methods[a.route], method)
+		}
+	}
+
+	for _, route := range routes {
+		*infos = append(*infos, newRouteInfo(route, methods[route]))
+	}
+
+	if n.mount != nil {
+		info := newRouteInfo(n.mount.route, nil)
+		info.IsMount = true
+
+		*infos = append(*infos, info)
+	}
+
+	labels := make([]string, 0, len(n.children))
+	for label, child := range n.children {
+		if child.kind == kindStatic {
+			labels = append(labels, label)
+		}
+	}
+
+	sort.Strings(labels)
+
+	for _, label := range labels {
+		n.children[label].collect(infos)
+	}
+
+	for _, child := range n.params {
+		child.collect(infos)
+	}
+
+	if n.wildcard != nil {
+		n.wildcard.collect(infos)
+	}
+}
+
+// newRouteInfo constructs and returns a new RouteInfo for the given Route record.
+func newRouteInfo(route *Route, methods []string) RouteInfo {
+	info := RouteInfo{
+		Pattern:       route.path,
+		Host:          route.host,
+		Methods:       methods,
+		Constraints:   make(map[string]string),
+		Middlewares:   len(route.middlewares),
+		Matchers:      len(route.matchers),
+		Produces:      route.produces,
+		Name:          route.name,
+		IsFileHandler: route.isFileHandler,
+	}
+
+	if route.version != nil {
+		info.Version = route.version.Name()
+	}
+
+	for _, segment := range expandPath(route.path) {
+		switch deriveNodeKind(segment) {
+		case kindCatchAll:
+			info.Params = append(info.Params, deriveCatchAllKey(segment))
+
+		case kindComposite:
+			pieces, _ := parseComposite(segment)
+			for _, piece := range pieces {
+				if piece.key == "" {
+					continue
+				}
+
+				if piece.pattern != "" {
+					info.Constraints[piece.key] = piece.pattern
+				}
+
+				info.Params = append(info.Params, piece.key)
+			}
+
+		case kindRegex:
+			info.Constraints[deriveParameterKey(segment)] = deriveLabelPattern(segment)
+			fallthrough
+
+		case kindParameter:
+			info.Params = append(info.Params, deriveParameterKey(segment))
+		}
+	}
+
+	return info
+}
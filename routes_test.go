@@ -0,0 +1,98 @@
+package turnpike
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestRoutes(t *testing.T) {
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	r := NewRouter()
+	r.UseGlobal(first)
+	r.WithMethods(http.MethodPost, http.MethodGet).Handler("/users/:id[^\\d+$]", testHandler).Use(second, third).Name("user.show").MustRegister()
+	r.WithMethods(http.MethodDelete).Handler("/users/:id[^\\d+$]", testHandler).MustRegister()
+	r.WithMethods(http.MethodGet).Handler("/users/me", testHandler).MustRegister()
+	r.WithMethods(http.MethodGet).Handler("/", testHandler).MustRegister()
+	r.FileHandler("/static", &mockFileSystem{}).MustRegister()
+	r.Group("/api", func(g *Group) {
+		g.Use(first).Mount("/billing", testHandler)
+	})
+
+	expected := []RouteInfo{
+		{
+			Pattern:     "/",
+			Methods:     []string{http.MethodGet},
+			Constraints: map[string]string{},
+		},
+		{
+			Pattern:     "/api/billing",
+			Constraints: map[string]string{},
+			Middlewares: 1,
+			IsMount:     true,
+		},
+		{
+			Pattern:       "/static/*filepath",
+			Methods:       []string{http.MethodGet},
+			Params:        []string{"filepath"},
+			Constraints:   map[string]string{},
+			IsFileHandler: true,
+		},
+		{
+			Pattern:     "/users/me",
+			Methods:     []string{http.MethodGet},
+			Constraints: map[string]string{},
+		},
+		{
+			Pattern:     "/users/:id[^\\d+$]",
+			Methods:     []string{http.MethodDelete},
+			Params:      []string{"id"},
+			Constraints: map[string]string{"id": "^\\d+$"},
+		},
+		{
+			Pattern:     "/users/:id[^\\d+$]",
+			Methods:     []string{http.MethodGet, http.MethodPost},
+			Params:      []string{"id"},
+			Constraints: map[string]string{"id": "^\\d+$"},
+			Middlewares: 2,
+			Name:        "user.show",
+		},
+	}
+
+	actual := r.Routes()
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %d routes but got %d: %v", len(expected), len(actual), actual)
+	}
+
+	for i := range expected {
+		if !reflect.DeepEqual(actual[i], expected[i]) {
+			t.Errorf("expected route %d to be %+v but got %+v", i, expected[i], actual[i])
+		}
+	}
+}
+
+func TestWalkError(t *testing.T) {
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	r := NewRouter()
+	r.WithMethods(http.MethodGet).Handler("/a", testHandler).MustRegister()
+	r.WithMethods(http.MethodGet).Handler("/b", testHandler).MustRegister()
+
+	errWalk := errors.New("stop")
+	visited := 0
+
+	err := r.Walk(func(info RouteInfo) error {
+		visited++
+		return errWalk
+	})
+
+	if err != errWalk {
+		t.Errorf("expected error %v but got %v", errWalk, err)
+	}
+
+	if visited != 1 {
+		t.Errorf("expected the walk to stop after 1 route but visited %d", visited)
+	}
+}
@@ -1,13 +1,142 @@
 package turnpike
 
 import (
+	"fmt"
 	"net/http"
+	"regexp"
+	"sort"
+	"strings"
 )
 
 // action represents an HTTP handler action.
 type action struct {
 	handler     http.Handler
 	middlewares middlewares
+	// trailingSlash indicates the route record was registered with a trailing PathDelimiter.
+	trailingSlash bool
+	// route holds the Route record the action was registered by.
+	route *Route
+	// matchers holds the RequestMatchers a request must satisfy to be served by the action.
+	matchers []RequestMatcher
+	// produces holds the media types the action produces, if declared.
+	produces []string
+	// version holds the API version the action is registered against, if any.
+	version *Version
+	// keys maps the keys of the parameters captured along the action's node to those of the Route record's path,
+	// where they differ. Route records whose paths differ only in their parameter keys share nodes.
+	keys map[string]string
+	// next holds the next action registered for the same path and HTTP method, if any. Conditional actions
+	// precede, in order of registration, the unconditional actions, of which there is at most one per API version.
+	next *action
+}
+
+// conditional determines whether the action serves only some requests for its path and HTTP method, i.e. whether
+// it has matchers or declares the media types it produces.
+func (a *action) conditional() bool {
+	return len(a.matchers) > 0 || len(a.produces) > 0
+}
+
+// add returns the chain of actions headed by the action with the given action added: after every conditional
+// action if it is conditional, and otherwise at the end of the chain.
+func (a *action) add(next *action) *action {
+	if a == nil {
+		return next
+	}
+
+	if next.conditional() && !a.conditional() {
+		next.next = a
+		return next
+	}
+
+	a.next = a.next.add(next)
+
+	return a
+}
+
+// fallback returns the unconditional action registered against the given API version in the chain of actions
+// headed by the action, if extant.
+func (a *action) fallback(version *Version) *action {
+	for ; a != nil; a = a.next {
+		if !a.conditional() && a.version == version {
+			return a
+		}
+	}
+
+	return nil
+}
+
+// duplicate returns the action in the chain headed by the action that the given Route record would duplicate, if
+// extant: that registered against the same API version, without matchers, and producing the same media types.
+// A Route record with matchers duplicates no action, as matchers cannot be compared.
+func (a *action) duplicate(route *Route) *action {
+	if len(route.matchers) > 0 {
+		return nil
+	}
+
+	for ; a != nil; a = a.next {
+		if len(a.matchers) == 0 && a.version == route.version && sameMediaTypes(a.produces, route.produces) {
+			return a
+		}
+	}
+
+	return nil
+}
+
+// without returns the chain of actions headed by the action, less the given action.
+func (a *action) without(target *action) *action {
+	if a == nil {
+		return nil
+	}
+
+	if a == target {
+		return a.next
+	}
+
+	a.next = a.next.without(target)
+
+	return a
+}
+
+// rename returns the given parameters captured along the action's node, keyed as in the action's Route record.
+func (a *action) rename(params []*parameter) []*parameter {
+	if len(a.keys) == 0 {
+		return params
+	}
+
+	renamed := make([]*parameter, len(params))
+	for i, param := range params {
+		renamed[i] = param
+		if key, ok := a.keys[param.key]; ok {
+			renamed[i] = &parameter{key: key, value: param.value}
+		}
+	}
+
+	return renamed
+}
+
+// candidates returns the actions in the chain of actions headed by the action whose matchers the request satisfies,
+// in chain order.
+func (a *action) candidates(req *http.Request) []*action {
+	var actions []*action
+
+	for ; a != nil; a = a.next {
+		if a.satisfies(req) {
+			actions = append(actions, a)
+		}
+	}
+
+	return actions
+}
+
+// satisfies determines whether the request satisfies each of the action's matchers.
+func (a *action) satisfies(req *http.Request) bool {
+	for _, matcher := range a.matchers {
+		if !matcher(req) {
+			return false
+		}
+	}
+
+	return true
 }
 
 // parameter represents a path parameter.
@@ -20,18 +149,79 @@ type parameter struct {
 type result struct {
 	actions    *action
 	parameters []*parameter
+	// mounted indicates the result belongs to a mounted handler, which is to be passed the remainder path.
+	mounted   bool
+	remainder string
+	// node holds the matched node of a route record.
+	node *node
+	// path holds the search path as matched, with static segments in their registered case.
+	path string
+	// allowed holds the HTTP methods allowed at the matched path, if the search failed with ErrMethodNotAllowed.
+	allowed []string
 }
 
 // trie is a trie data structure used to manage multiplexing paths.
 type trie struct {
 	root *node
+	// allowOverride permits an insertion to replace the actions registered for the same path and method.
+	allowOverride bool
 }
 
+// nodeKind classifies a trie node by the kind of path segment it matches.
+// Kinds are declared in order of precedence, from the most to the least specific:
+//   - kindStatic matches a segment verbatim, e.g. /users/me
+//   - kindComposite matches a segment mixing literal text and parameters, e.g. /files/:name.:ext
+//   - kindRegex matches a parameter constrained by a regex pattern, e.g. /users/:id[^\d+$]
+//   - kindParameter matches any segment as a parameter, e.g. /users/:slug
+//   - kindCatchAll matches the remainder of the path, e.g. /users/*rest
+type nodeKind int
+
+const (
+	kindStatic nodeKind = iota
+	kindComposite
+	kindRegex
+	kindParameter
+	kindCatchAll
+)
+
 // node is a trie node.
 type node struct {
+	kind     nodeKind
 	label    string
 	children map[string]*node
 	actions  map[string]*action
+	mount    *action
+	// pattern holds the path pattern qualifying the node, e.g. /users/:id.
+	pattern string
+	// key holds the parameter key of a parameter or catch-all node.
+	key string
+	// keys holds the parameter keys of a composite node, in the order in which they appear in its label.
+	keys []string
+	// regex holds the compiled pattern of a regex parameter or composite node.
+	regex *regexp.Regexp
+	// params holds the node's parameter children in order of precedence; children of the same kind
+	// are ordered by insertion.
+	params []*node
+	// wildcard holds the node's catch-all child, if extant.
+	wildcard *node
+	// folded holds the node's static children keyed by their lower-cased labels, for case-insensitive matching.
+	// Children whose labels differ only in case share a key, in order of insertion.
+	folded map[string][]*node
+	// optional indicates the node is an optional parameter node, which also matches an absent path segment.
+	optional bool
+	// parent holds the node's parent, if extant.
+	parent *node
+}
+
+// searcher holds the state of a single trie search.
+type searcher struct {
+	method     string
+	searchPath string
+	paths      []string
+	// fold enables matching static segments case-insensitively.
+	fold bool
+	// canonical holds the path segments as matched, with static segments in their registered case.
+	canonical []string
 }
 
 var rc = newCache()
@@ -51,129 +241,804 @@ func newTrie() *trie {
 	}
 }
 
-// insert inserts a new routing result into the trie.
-func (t *trie) insert(methods []string, path string, handler http.Handler, mws middlewares) error {
+// insertRoute inserts a new routing result for the given Route record into the trie.
+func (t *trie) insertRoute(route *Route) error {
+	path := route.path
+
+	// A Route record with matchers or media types is never ambiguous, as it serves only the requests they qualify:
+	// of overlapping paths, a request path is matched against that taking precedence per search, whose Route records'
+	// matchers alone then apply.
+	conditional := len(route.matchers) > 0 || len(route.produces) > 0
+
+	// An overlapping path is ambiguous if it is a mount, or if it unconditionally serves any of the Route record's
+	// methods for its API version, as would a duplicate.
+	curr, err := t.walk(path, func(n *node) bool {
+		if n.mount != nil {
+			return true
+		}
+
+		if conditional {
+			return false
+		}
+
+		for _, equivalent := range n.equivalents() {
+			for _, method := range route.methods {
+				if equivalent.actions[method].fallback(route.version) != nil {
+					return true
+				}
+			}
+		}
+
+		return false
+	})
+	if err != nil {
+		return err
+	}
+
+	keys := curr.renamedKeys(path)
+
+	// Nodes whose paths differ only in trailing optional parameters match the same request paths.
+	equivalents := curr.equivalents()
+
+	for _, method := range route.methods {
+		for _, n := range equivalents {
+			existing := n.actions[method].duplicate(route)
+			if existing == nil {
+				continue
+			}
+
+			// A Route record whose path names different parameters is ambiguous rather than a duplicate, and cannot
+			// override the other.
+			if n == curr && !sameKeys(existing.keys, keys) {
+				return newConflictError(method, path, existing.route.path, ErrAmbiguousRoute)
+			}
+
+			if !t.allowOverride {
+				return newConflictError(method, path, n.fullPath(), ErrDuplicateRoute)
+			}
+		}
+	}
+
+	for _, method := range route.methods {
+		for _, n := range equivalents {
+			existing := n.actions[method].duplicate(route)
+			if existing == nil {
+				continue
+			}
+
+			if next := n.actions[method].without(existing); next != nil {
+				n.actions[method] = next
+			} else {
+				delete(n.actions, method)
+			}
+		}
+
+		curr.actions[method] = curr.actions[method].add(&action{
+			handler:       route.handler,
+			middlewares:   route.middlewares,
+			trailingSlash: path != PathRoot && strings.HasSuffix(path, PathDelimiter),
+			route:         route,
+			matchers:      route.matchers,
+			produces:      route.produces,
+			version:       route.version,
+			keys:          keys,
+		})
+	}
+
+	return nil
+}
+
+// insertMount inserts into the trie a handler that owns the entire subtree qualified by the Route record's path.
+func (t *trie) insertMount(route *Route) error {
+	// A path of the same shape is ambiguous if it serves any method, or is a mount.
+	curr, err := t.walk(route.path, func(n *node) bool {
+		return n.mount != nil || len(n.actions) > 0
+	})
+	if err != nil {
+		return err
+	}
+
+	if curr.mount != nil && !t.allowOverride {
+		return newConflictError("", route.path, curr.fullPath(), ErrDuplicateRoute)
+	}
+
+	curr.mount = &action{
+		handler:     route.handler,
+		middlewares: route.middlewares,
+		route:       route,
+		keys:        curr.renamedKeys(route.path),
+	}
+
+	return nil
+}
+
+// walk walks the trie along the given path, creating any missing nodes, and returns the last node. Paths that differ
+// only in their parameter keys share nodes (see node.child).
+// The path is validated and its parameter patterns compiled before the trie is modified, such that
+// an invalid path leaves the trie untouched. So too is the path checked for ambiguity: walk fails if `overlaps`
+// reports any other path that would match the same request paths, save for optional parameters.
+func (t *trie) walk(path string, overlaps func(n *node) bool) (*node, error) {
 	curr := t.root
 
 	// Handle root path
 	if path == PathRoot {
 		curr.label = path
-		for _, method := range methods {
-			curr.actions[method] = &action{
-				handler:     handler,
-				middlewares: mws,
+		return curr, nil
+	}
+
+	if err := validateCatchAll(path); err != nil {
+		return nil, err
+	}
+
+	segments := expandPath(path)
+
+	regexes := make([]*regexp.Regexp, len(segments))
+	for i, segment := range segments {
+		var pattern string
+
+		switch deriveNodeKind(segment) {
+		case kindParameter:
+			if err := validateParameter(segment); err != nil {
+				return nil, err
 			}
+
+			continue
+
+		case kindRegex:
+			if err := validateParameter(segment); err != nil {
+				return nil, err
+			}
+
+			pattern = deriveLabelPattern(segment)
+
+		case kindComposite:
+			pieces, err := parseComposite(segment)
+			if err != nil {
+				return nil, err
+			}
+
+			pattern = compositePattern(pieces, true)
+
+		default:
+			continue
 		}
 
-		return nil
+		regex, err := rc.get(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w: segment %s: %v", ErrInvalidPattern, segment, err)
+		}
+
+		regexes[i] = regex
 	}
 
-	paths := expandPath(path)
-	for i, splitPath := range paths {
-		next, ok := curr.children[splitPath]
+	for _, n := range t.root.counterparts(segments) {
+		if overlaps(n) {
+			return nil, newConflictError("", path, n.fullPath(), ErrAmbiguousRoute)
+		}
+	}
 
-		if ok {
+	for i, segment := range segments {
+		if next := curr.child(segment); next != nil {
 			curr = next
-		} else {
-			curr.children[splitPath] = &node{
-				label:    splitPath,
-				actions:  make(map[string]*action),
-				children: make(map[string]*node),
-			}
-			curr = curr.children[splitPath]
-		}
-
-		// Overwrite existing data on last path
-		if i == len(paths)-1 {
-			curr.label = splitPath
-			for _, method := range methods {
-				curr.actions[method] = &action{
-					handler:     handler,
-					middlewares: mws,
+			continue
+		}
+
+		next := &node{
+			kind:     deriveNodeKind(segment),
+			label:    segment,
+			pattern:  joinPath(curr.pattern, segment),
+			regex:    regexes[i],
+			optional: isOptional(segment),
+			parent:   curr,
+			actions:  make(map[string]*action),
+			children: make(map[string]*node),
+		}
+
+		switch next.kind {
+		case kindStatic:
+			if curr.folded == nil {
+				curr.folded = make(map[string][]*node)
+			}
+
+			folded := strings.ToLower(segment)
+			curr.folded[folded] = append(curr.folded[folded], next)
+
+		case kindComposite:
+			pieces, _ := parseComposite(segment)
+			for _, piece := range pieces {
+				if piece.key != "" {
+					next.keys = append(next.keys, piece.key)
 				}
 			}
 
+			curr.insertParam(next)
+
+		case kindRegex, kindParameter:
+			next.key = deriveParameterKey(segment)
+			curr.insertParam(next)
+
+		case kindCatchAll:
+			next.key = deriveCatchAllKey(segment)
+			curr.wildcard = next
+		}
+
+		curr.children[segment] = next
+		curr = next
+	}
+
+	return curr, nil
+}
+
+// child returns the child of the node that matches exactly the same path segments as a child labeled `label`: that
+// labeled `label`, or else a parameter child of the same shape and optionality, if extant.
+// e.g. :uid for :id, *other for *rest
+func (n *node) child(label string) *node {
+	if next, ok := n.children[label]; ok {
+		return next
+	}
+
+	switch deriveNodeKind(label) {
+	case kindStatic:
+		return nil
+
+	case kindCatchAll:
+		return n.wildcard
+	}
+
+	shape := segmentShape(label)
+	for _, param := range n.params {
+		if segmentShape(param.label) == shape && param.optional == isOptional(label) {
+			return param
+		}
+	}
+
+	return nil
+}
+
+// renamedKeys maps the keys of the parameters captured along the path to the node to those of the given path of the
+// same shape, where they differ.
+// e.g. /users/:id/posts, /users/:uid/posts → id: uid
+func (n *node) renamedKeys(path string) map[string]string {
+	var nodes []*node
+	for curr := n; curr.parent != nil; curr = curr.parent {
+		nodes = append([]*node{curr}, nodes...)
+	}
+
+	var keys map[string]string
+
+	for i, segment := range expandPath(path) {
+		if i >= len(nodes) {
 			break
 		}
 
+		from, to := nodes[i].paramKeys(), segmentKeys(segment)
+		for j := range from {
+			if j < len(to) && from[j] != to[j] {
+				if keys == nil {
+					keys = make(map[string]string)
+				}
+
+				keys[from[j]] = to[j]
+			}
+		}
+	}
+
+	return keys
+}
+
+// paramKeys returns the keys of the parameters the node captures, in order.
+func (n *node) paramKeys() []string {
+	if n.kind == kindComposite {
+		return n.keys
+	}
+
+	if n.key != "" {
+		return []string{n.key}
 	}
 
 	return nil
 }
 
-// search searches a given path and method in the trie's routing results.
-func (t *trie) search(method string, searchPath string) (*result, error) {
-	var params []*parameter
-	result := newResult()
-	curr := t.root
+// segmentKeys returns the keys of the parameters the given path segment label captures, in order.
+// e.g. :name.:ext → name, ext
+func segmentKeys(label string) []string {
+	switch deriveNodeKind(label) {
+	case kindCatchAll:
+		return []string{deriveCatchAllKey(label)}
 
-	for _, path := range expandPath(searchPath) {
-		next, ok := curr.children[path]
+	case kindComposite:
+		var keys []string
 
-		if ok {
-			curr = next
-			continue
+		pieces, _ := parseComposite(label)
+		for _, piece := range pieces {
+			if piece.key != "" {
+				keys = append(keys, piece.key)
+			}
 		}
 
-		if len(curr.children) == 0 {
-			if curr.label != path {
-				// No matching route result found.
-				return nil, ErrNotFound
+		return keys
+
+	case kindRegex, kindParameter:
+		return []string{deriveParameterKey(label)}
+	}
+
+	return nil
+}
+
+// sameMediaTypes determines whether two lists of media types hold the same media types, disregarding order and case.
+func sameMediaTypes(a []string, b []string) bool {
+	set := make(map[string]bool, len(a))
+	for _, mediaType := range a {
+		set[strings.ToLower(mediaType)] = true
+	}
+
+	other := make(map[string]bool, len(b))
+	for _, mediaType := range b {
+		if !set[strings.ToLower(mediaType)] {
+			return false
+		}
+
+		other[strings.ToLower(mediaType)] = true
+	}
+
+	return len(set) == len(other)
+}
+
+// sameKeys determines whether two key renamings are equivalent.
+func sameKeys(a map[string]string, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for key, value := range a {
+		if b[key] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// counterparts returns the nodes descending from the node along paths that match the same request paths as the
+// given path segments, save for optional parameters, other than the nodes the path itself walks.
+// e.g. /users/:id? for /users/:uid
+func (n *node) counterparts(segments []string) []*node {
+	nodes := []*node{n}
+	exact := n
+
+	for _, segment := range segments {
+		shape := segmentShape(segment)
+
+		var next []*node
+		for _, curr := range nodes {
+			switch deriveNodeKind(segment) {
+			case kindStatic:
+				if child, ok := curr.children[segment]; ok {
+					next = append(next, child)
+				}
+
+			case kindCatchAll:
+				if curr.wildcard != nil {
+					next = append(next, curr.wildcard)
+				}
+
+			default:
+				for _, param := range curr.params {
+					if segmentShape(param.label) == shape {
+						next = append(next, param)
+					}
+				}
+			}
+		}
+
+		nodes = next
+
+		if exact != nil {
+			exact = exact.child(segment)
+		}
+	}
+
+	counterparts := nodes[:0]
+	for _, curr := range nodes {
+		if curr != exact {
+			counterparts = append(counterparts, curr)
+		}
+	}
+
+	return counterparts
+}
+
+// segmentShape returns the shape of the given path segment label, such that labels of equal shape match exactly the
+// same path segments. Parameters of equal shape share a pattern, but not necessarily a key; composite parameters, so
+// too their literal text.
+func segmentShape(label string) string {
+	switch deriveNodeKind(label) {
+	case kindParameter:
+		return ParameterDelimiter
+
+	case kindRegex:
+		return ParameterDelimiter + deriveLabelPattern(label)
+
+	case kindComposite:
+		pieces, _ := parseComposite(label)
+		return compositePattern(pieces, false)
+
+	case kindCatchAll:
+		return CatchAllDelimiter
+	}
+
+	return label
+}
+
+// equivalents returns the nodes that match the same request paths as the node by way of absent trailing optional
+// parameters, including the node itself: the ancestors the node's trailing optional parameter nodes descend from,
+// and the optional parameter nodes descending from those.
+func (n *node) equivalents() []*node {
+	top := n
+	for top.optional && top.parent != nil {
+		top = top.parent
+	}
+
+	nodes := []*node{top}
+	for i := 0; i < len(nodes); i++ {
+		for _, param := range nodes[i].params {
+			if param.optional {
+				nodes = append(nodes, param)
 			}
+		}
+	}
+
+	return nodes
+}
+
+// kinds returns the kinds of the nodes along the path from the root to the node, in that order.
+func (n *node) kinds() []nodeKind {
+	var kinds []nodeKind
+	for curr := n; curr.parent != nil; curr = curr.parent {
+		kinds = append([]nodeKind{curr.kind}, kinds...)
+	}
+
+	return kinds
+}
+
+// allowed returns the HTTP methods registered at the node, in lexical order.
+func (n *node) allowed() []string {
+	methods := make([]string, 0, len(n.actions))
+	for method := range n.actions {
+		methods = append(methods, method)
+	}
+
+	sort.Strings(methods)
+
+	return methods
+}
+
+// fullPath returns the path pattern qualifying the node.
+func (n *node) fullPath() string {
+	if n.pattern == "" {
+		return PathRoot
+	}
+
+	return n.pattern
+}
+
+// insertParam inserts a parameter child into the node's params, after every child of the same or greater precedence.
+func (n *node) insertParam(child *node) {
+	i := len(n.params)
+	for j, param := range n.params {
+		if param.kind > child.kind {
+			i = j
 			break
 		}
+	}
 
-		isParamMatch := false
-		for child := range curr.children {
-			if string([]rune(child)[0]) == ParameterDelimiter {
-				pattern := deriveLabelPattern(child)
-				regex, err := rc.get(pattern)
+	n.params = append(n.params, nil)
+	copy(n.params[i+1:], n.params[i:])
+	n.params[i] = child
+}
 
-				if err != nil {
-					return nil, ErrNotFound
-				}
+// deriveNodeKind derives from a given label the kind of node that matches it.
+func deriveNodeKind(label string) nodeKind {
+	switch {
+	case isCatchAll(label):
+		return kindCatchAll
+	case isComposite(label):
+		return kindComposite
+	case isParameter(label) && strings.Contains(label, PatternDelimiterStart):
+		return kindRegex
+	case isParameter(label):
+		return kindParameter
+	default:
+		return kindStatic
+	}
+}
+
+// search searches a given path and method in the trie's routing results.
+//
+// Each path segment is matched against the current node's children in order of precedence (see nodeKind):
+// the static child qualified by the segment, then each composite child, then each regex parameter child, then each plain parameter child,
+// then the catch-all child. An optional parameter child is tried both as consuming the segment and, failing that,
+// as absent. Should a candidate fail to match the remainder of the path, the search backtracks and tries the next
+// candidate.
+// If no route record matches, the search falls back to the nearest mounted handler along the path, if extant.
+func (t *trie) search(method string, searchPath string) (*result, error) {
+	return newSearcher(method, searchPath, false).search(t.root)
+}
 
-				if regex.Match([]byte(path)) {
-					param := deriveParameterKey(child)
+// searchFold searches a given path and method in the trie's routing results as search does, but matches
+// static segments case-insensitively. An exact match takes precedence over a case-insensitive one. Of the static
+// children whose labels differ from the segment only in case, each is tried, and the most specific match is selected.
+func (t *trie) searchFold(method string, searchPath string) (*result, error) {
+	return newSearcher(method, searchPath, true).search(t.root)
+}
 
-					params = append(params, &parameter{
-						key:   param,
-						value: path,
-					})
+// newSearcher constructs and returns a pointer to a new searcher.
+func newSearcher(method string, searchPath string, fold bool) *searcher {
+	paths := expandPath(searchPath)
 
-					curr = curr.children[child]
+	s := &searcher{
+		method:     method,
+		searchPath: searchPath,
+		paths:      paths,
+		fold:       fold,
+		canonical:  paths,
+	}
+
+	if fold {
+		s.canonical = append([]string(nil), paths...)
+	}
 
-					isParamMatch = true
-					break
+	return s
+}
+
+// search searches the subtree rooted at node `root`. A successful result holds the search path as matched.
+func (s *searcher) search(root *node) (*result, error) {
+	result, err := s.match(root, 0, nil)
+	if err == nil {
+		result.path = PathDelimiter + joinRemainder(s.canonical, s.searchPath)
+	}
+
+	return result, err
+}
+
+// match matches the path segments from index `i` onwards against the subtree rooted at node `n`.
+// ErrMethodNotAllowed takes precedence over ErrNotFound when every candidate fails, in which case the result
+// of the first candidate whose path matched holds the HTTP methods allowed there.
+func (s *searcher) match(n *node, i int, params []*parameter) (*result, error) {
+	if i == len(s.paths) {
+		return s.resolve(n, params)
+	}
+
+	path := s.paths[i]
+	var notAllowed *result
+
+	// Record a failed candidate, retaining the first whose path matched, but not its method.
+	fail := func(result *result, err error) {
+		if err == ErrMethodNotAllowed && notAllowed == nil {
+			notAllowed = result
+		}
+	}
+
+	if next, ok := n.children[path]; ok && next.kind == kindStatic {
+		result, err := s.match(next, i+1, params)
+		if err == nil {
+			return result, nil
+		}
+		fail(result, err)
+	}
+
+	if s.fold {
+		var (
+			best      *result
+			canonical []string
+		)
+
+		for _, next := range n.folded[strings.ToLower(path)] {
+			if next.label == path {
+				continue
+			}
+
+			s.canonical[i] = next.label
+
+			result, err := s.match(next, i+1, params)
+			if err == nil {
+				if best == nil || moreSpecific(result, best) {
+					best = result
+					canonical = append(canonical[:0], s.canonical...)
 				}
+				continue
+			}
+			fail(result, err)
+		}
 
-				// No parameter match.
-				return nil, ErrNotFound
+		s.canonical[i] = path
+
+		if best != nil {
+			copy(s.canonical, canonical)
+			return best, nil
+		}
+	}
+
+	for _, next := range n.params {
+		if captured, ok := next.capture(params, path); ok {
+			result, err := s.match(next, i+1, captured)
+			if err == nil {
+				return result, nil
+			}
+			fail(result, err)
+		}
+
+		// An optional parameter also matches an absent segment, in which case it consumes nothing.
+		if next.optional {
+			result, err := s.match(next, i, params)
+			if err == nil {
+				return result, nil
 			}
+			fail(result, err)
 		}
+	}
 
-		// No parameter match.
-		if !isParamMatch {
-			return nil, ErrNotFound
+	// A catch-all consumes the remainder of the path.
+	if next := n.wildcard; next != nil {
+		value := joinRemainder(s.paths[i:], s.searchPath)
 
+		result, err := s.resolve(next, withParameter(params, next.key, value))
+		if err == nil {
+			return result, nil
 		}
+		fail(result, err)
+	}
+
+	if notAllowed != nil {
+		return notAllowed, ErrMethodNotAllowed
 	}
 
-	if searchPath == PathRoot {
-		// No matching handler.
-		if len(curr.actions) == 0 {
-			return nil, ErrNotFound
+	if n.mount != nil {
+		return newMountResult(n, params, s.paths[i:], s.searchPath), nil
+	}
+
+	return nil, ErrNotFound
+}
+
+// moreSpecific reports whether result `a` is more specific than result `b`, i.e. whether the first node kind along
+// the path matched by `a` that differs from that along `b` takes precedence. Mounted results are least specific.
+func moreSpecific(a *result, b *result) bool {
+	if a.node == nil || b.node == nil {
+		return b.node == nil && a.node != nil
+	}
+
+	kindsA, kindsB := a.node.kinds(), b.node.kinds()
+	for i := 0; i < len(kindsA) && i < len(kindsB); i++ {
+		if kindsA[i] != kindsB[i] {
+			return kindsA[i] < kindsB[i]
 		}
 	}
 
-	result.actions = curr.actions[method]
+	return false
+}
+
+// resolve resolves the routing result of node `n`, at which the path has been entirely consumed.
+func (s *searcher) resolve(n *node, params []*parameter) (*result, error) {
+	if actions := n.actions[s.method]; actions != nil {
+		result := newResult()
+		result.actions = actions
+		result.parameters = params
+		result.node = n
+
+		return result, nil
+	}
+
+	// A mount point owns the node at which it is mounted, save for the methods explicitly registered there.
+	if n.mount != nil {
+		return newMountResult(n, params, nil, s.searchPath), nil
+	}
+
+	allowed := n.allowed()
+
+	// An optional parameter child matches the absent segment following the end of the path.
+	for _, next := range n.params {
+		if !next.optional {
+			continue
+		}
+
+		result, err := s.resolve(next, params)
+		if err == nil {
+			return result, nil
+		}
+
+		if err == ErrMethodNotAllowed {
+			allowed = mergeMethods(allowed, result.allowed)
+		}
+	}
 
 	// No matching handler.
-	if result.actions == nil {
-		return nil, ErrMethodNotAllowed
+	if len(allowed) > 0 {
+		result := newResult()
+		result.allowed = allowed
+
+		return result, ErrMethodNotAllowed
+	}
+
+	// A catch-all also matches an empty remainder.
+	if next := n.wildcard; next != nil {
+		return s.resolve(next, withParameter(params, next.key, ""))
+	}
+
+	// No matching route result found.
+	return nil, ErrNotFound
+}
+
+// capture matches the given path segment against parameter or composite node `n`. If the segment matches, capture
+// returns a copy of the given parameters with those the segment captures appended.
+func (n *node) capture(params []*parameter, segment string) ([]*parameter, bool) {
+	switch {
+	case n.kind == kindComposite:
+		values := n.regex.FindStringSubmatch(segment)
+		if values == nil {
+			return nil, false
+		}
+
+		for _, key := range n.keys {
+			params = withParameter(params, key, values[n.regex.SubexpIndex(key)])
+		}
+
+		return params, true
+
+	case n.regex != nil && !n.regex.MatchString(segment):
+		return nil, false
+
+	default:
+		return withParameter(params, n.key, segment), true
+	}
+}
+
+// mergeMethods returns the union of the given lexically ordered HTTP methods, in lexical order.
+func mergeMethods(a []string, b []string) []string {
+	merged := append([]string(nil), a...)
+
+	for _, method := range b {
+		if !contains(merged, method) {
+			merged = append(merged, method)
+		}
+	}
+
+	sort.Strings(merged)
+
+	return merged
+}
+
+// withParameter returns a copy of the given parameters with a new parameter appended.
+// Copying ensures that sibling candidates in a backtracking search do not share captured parameters.
+func withParameter(params []*parameter, key string, value string) []*parameter {
+	return append(params[:len(params):len(params)], &parameter{
+		key:   key,
+		value: value,
+	})
+}
+
+// canonicalPath returns the canonical form of the clean path `path` the result was searched for.
+// The canonical form of a route record's path carries a trailing PathDelimiter only if the route record was
+// registered with one. Paths matched by a catch-all or a mount point retain the request's trailing PathDelimiter.
+func (r *result) canonicalPath(path string) string {
+	if r.mounted || r.node.kind == kindCatchAll || path == PathRoot {
+		return path
 	}
 
-	result.parameters = params
+	path = strings.TrimSuffix(path, PathDelimiter)
+	if r.actions.trailingSlash {
+		path += PathDelimiter
+	}
 
-	return result, nil
+	return path
+}
+
+// newMountResult constructs and returns a pointer to a new result for the mount point at node `n`.
+// The remainder path is built from the unconsumed path segments, preserving the search path's trailing delimiter.
+func newMountResult(n *node, params []*parameter, rest []string, searchPath string) *result {
+	return &result{
+		actions:    n.mount,
+		parameters: params,
+		mounted:    true,
+		remainder:  PathDelimiter + joinRemainder(rest, searchPath),
+	}
 }
@@ -2,6 +2,7 @@ package turnpike
 
 // @todo refactor: reusability, setup/teardown
 import (
+	"errors"
 	"net/http"
 	"reflect"
 	"testing"
@@ -39,7 +40,7 @@ func TestInsert(t *testing.T) {
 		},
 		{
 			path:        PathRoot,
-			methods:     []string{http.MethodGet, http.MethodPost},
+			methods:     []string{http.MethodPost, http.MethodPut},
 			handler:     testHandler,
 			middlewares: []middleware{first, second, third},
 		},
@@ -78,12 +79,195 @@ func TestInsert(t *testing.T) {
 	trie := newTrie()
 
 	for i, record := range records {
-		if err := trie.insert(record.methods, record.path, record.handler, record.middlewares); err != nil {
+		if err := trie.insertRoute(&Route{methods: record.methods, path: record.path, handler: record.handler, middlewares: record.middlewares}); err != nil {
 			t.Errorf("error %v inserting test %d\n", err, i)
 		}
 	}
 }
 
+func TestInsertError(t *testing.T) {
+	type testCase struct {
+		name     string
+		path     string
+		methods  []string
+		expected error
+	}
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	trie := newTrie()
+	trie.insertRoute(&Route{methods: []string{http.MethodGet, http.MethodPost}, path: "/test/:id", handler: testHandler})
+	trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/files/:name.:ext", handler: testHandler})
+
+	tests := []testCase{
+		{name: "DuplicateRoute", path: "/test/:id", methods: []string{http.MethodGet}, expected: ErrDuplicateRoute},
+		{name: "PartialDuplicateRoute", path: "/test/:id/", methods: []string{http.MethodPut, http.MethodPost}, expected: ErrDuplicateRoute},
+		{name: "InnerCatchAll", path: "/test/*rest/more", methods: []string{http.MethodGet}, expected: ErrInvalidPattern},
+		{name: "UnnamedCatchAll", path: "/test/*", methods: []string{http.MethodGet}, expected: ErrInvalidPattern},
+		{name: "InvalidRegex", path: "/fresh/:id/:name[(]", methods: []string{http.MethodGet}, expected: ErrInvalidPattern},
+		{name: "AdjacentCompositeParameters", path: "/fresh/:a:b", methods: []string{http.MethodGet}, expected: ErrInvalidPattern},
+		{name: "InvalidCompositeRegex", path: "/fresh/:name.:ext[(]", methods: []string{http.MethodGet}, expected: ErrInvalidPattern},
+		{name: "AmbiguousComposite", path: "/files/:base.:suffix", methods: []string{http.MethodGet}, expected: ErrAmbiguousRoute},
+		{name: "UnterminatedPattern", path: "/fresh/:id[^\\d+$", methods: []string{http.MethodGet}, expected: ErrInvalidPattern},
+		{name: "UnnamedRegexParameter", path: "/fresh/:[^\\d+$]", methods: []string{http.MethodGet}, expected: ErrInvalidPattern},
+		{name: "UnnamedParameter", path: "/fresh/:", methods: []string{http.MethodGet}, expected: ErrInvalidPattern},
+		{name: "UnnamedOptionalParameter", path: "/fresh/:?", methods: []string{http.MethodGet}, expected: ErrInvalidPattern},
+		{name: "TrailingTextAfterPattern", path: "/fresh/:id[int]x", methods: []string{http.MethodGet}, expected: ErrInvalidPattern},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := trie.insertRoute(&Route{methods: test.methods, path: test.path, handler: testHandler}); !errors.Is(err, test.expected) {
+				t.Errorf("expected error %v but got %v", test.expected, err)
+			}
+		})
+	}
+
+	// An invalid path must leave the trie untouched.
+	if _, ok := trie.root.children["fresh"]; ok {
+		t.Error("expected an invalid path not to modify the trie")
+	}
+
+	// A partially duplicate insertion must not register any of its methods.
+	if _, err := trie.search(http.MethodPut, "/test/12"); err != ErrMethodNotAllowed {
+		t.Errorf("expected error %v but got %v", ErrMethodNotAllowed, err)
+	}
+}
+
+func TestInsertConflict(t *testing.T) {
+	type testCase struct {
+		name     string
+		path     string
+		expected error
+		existing string
+	}
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	trie := newTrie()
+	for _, path := range []string{"/a/:x", "/b/:id[^\\d+$]", "/c/*rest", "/d/:x/e", "/e", "/f/:year?"} {
+		if err := trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: path, handler: testHandler}); err != nil {
+			t.Fatalf("unexpected error %v inserting %s", err, path)
+		}
+	}
+
+	tests := []testCase{
+		{name: "Duplicate", path: "/a/:x", expected: ErrDuplicateRoute, existing: "/a/:x"},
+		{name: "DuplicateTrailingSlash", path: "/a/:x/", expected: ErrDuplicateRoute, existing: "/a/:x"},
+		{name: "AmbiguousParameter", path: "/a/:y", expected: ErrAmbiguousRoute, existing: "/a/:x"},
+		{name: "AmbiguousNestedParameter", path: "/d/:y/e", expected: ErrAmbiguousRoute, existing: "/d/:x/e"},
+		{name: "DistinctNestedParameter", path: "/d/:y/f"},
+		{name: "AmbiguousRegexParameter", path: "/b/:num[^\\d+$]", expected: ErrAmbiguousRoute, existing: "/b/:id[^\\d+$]"},
+		{name: "AmbiguousCatchAll", path: "/c/*other", expected: ErrAmbiguousRoute, existing: "/c/*rest"},
+		{name: "DistinctRegexParameter", path: "/b/:name[^[a-z]+$]"},
+		{name: "DistinctKinds", path: "/a/:x[^\\d+$]"},
+		{name: "SharedParameter", path: "/d/:x/g"},
+		{name: "DuplicateAbsentOptional", path: "/e/:x?", expected: ErrDuplicateRoute, existing: "/e"},
+		{name: "DuplicateOptionalAbsent", path: "/f", expected: ErrDuplicateRoute, existing: "/f/:year?"},
+		{name: "DuplicateNestedOptional", path: "/f/:year?/:month?", expected: ErrDuplicateRoute, existing: "/f/:year?"},
+		{name: "AmbiguousOptional", path: "/f/:y", expected: ErrAmbiguousRoute, existing: "/f/:year?"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: test.path, handler: testHandler})
+			if test.expected == nil {
+				if err != nil {
+					t.Errorf("expected no error but got %v", err)
+				}
+				return
+			}
+
+			var conflictErr *ConflictError
+			if !errors.As(err, &conflictErr) || !errors.Is(err, test.expected) {
+				t.Fatalf("expected a *ConflictError wrapping %v but got %v", test.expected, err)
+			}
+
+			if conflictErr.Existing != test.existing {
+				t.Errorf("expected conflicting route %s but got %s", test.existing, conflictErr.Existing)
+			}
+		})
+	}
+}
+
+func TestInsertDistinctSubtrees(t *testing.T) {
+	type testCase struct {
+		name     string
+		method   string
+		path     string
+		expected string
+		params   []*parameter
+	}
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	trie := newTrie()
+	for _, record := range []routeRecord{
+		{path: "/users/:id/posts", methods: []string{http.MethodGet}},
+		{path: "/users/:uid/comments", methods: []string{http.MethodGet}},
+		{path: "/users/:user/posts", methods: []string{http.MethodPost}},
+		{path: "/files/:name.:ext/meta", methods: []string{http.MethodGet}},
+		{path: "/files/:base.:suffix/raw", methods: []string{http.MethodGet}},
+	} {
+		if err := trie.insertRoute(&Route{methods: record.methods, path: record.path, handler: testHandler}); err != nil {
+			t.Fatalf("unexpected error %v inserting %s", err, record.path)
+		}
+	}
+
+	// Paths of the same shape remain ambiguous for the same methods.
+	if err := trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/users/:user/posts", handler: testHandler}); !errors.Is(err, ErrAmbiguousRoute) {
+		t.Errorf("expected error %v but got %v", ErrAmbiguousRoute, err)
+	}
+
+	tests := []testCase{
+		{name: "FirstSubtree", method: http.MethodGet, path: "/users/42/posts", expected: "/users/:id/posts", params: []*parameter{{key: "id", value: "42"}}},
+		{name: "SecondSubtree", method: http.MethodGet, path: "/users/42/comments", expected: "/users/:uid/comments", params: []*parameter{{key: "uid", value: "42"}}},
+		{name: "DistinctMethod", method: http.MethodPost, path: "/users/42/posts", expected: "/users/:user/posts", params: []*parameter{{key: "user", value: "42"}}},
+		{name: "CompositeSubtree", method: http.MethodGet, path: "/files/a.txt/raw", expected: "/files/:base.:suffix/raw", params: []*parameter{{key: "base", value: "a"}, {key: "suffix", value: "txt"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := trie.search(test.method, test.path)
+			if err != nil {
+				t.Fatalf("expected a result but got error %v", err)
+			}
+
+			if pattern := actual.actions.route.path; pattern != test.expected {
+				t.Errorf("expected route %s but got %s", test.expected, pattern)
+			}
+
+			// Paths that differ only in their parameter keys share nodes; each action renames the parameters.
+			if params := actual.actions.rename(actual.parameters); !reflect.DeepEqual(params, test.params) {
+				t.Errorf("expected parameters %v but got %v", test.params, params)
+			}
+		})
+	}
+}
+
+func TestInsertCompilesPatterns(t *testing.T) {
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	trie := newTrie()
+	if err := trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/users/:id[^\\d+$]/:slug/*rest", handler: testHandler}); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	id := trie.root.children["users"].children[":id[^\\d+$]"]
+	if id.regex == nil || id.regex.String() != "^\\d+$" || id.key != "id" {
+		t.Errorf("expected a compiled regex parameter node but got %v", id)
+	}
+
+	slug := id.children[":slug"]
+	if slug.regex != nil || slug.key != "slug" {
+		t.Errorf("expected an unconstrained parameter node but got %v", slug)
+	}
+
+	if rest := slug.wildcard; rest == nil || rest.key != "rest" {
+		t.Errorf("expected a catch-all node but got %v", rest)
+	}
+}
+
 func TestSearchResults(t *testing.T) {
 	type searchQuery struct {
 		method string
@@ -325,7 +509,7 @@ func TestSearchResults(t *testing.T) {
 	trie := newTrie()
 
 	for _, record := range insert {
-		trie.insert(record.methods, record.path, record.handler, record.middlewares)
+		trie.insertRoute(&Route{methods: record.methods, path: record.path, handler: record.handler, middlewares: record.middlewares})
 	}
 
 	for _, test := range tests {
@@ -444,7 +628,7 @@ func TestSearchError(t *testing.T) {
 	trie := newTrie()
 
 	for _, record := range insert {
-		trie.insert(record.methods, record.path, record.handler, record.middlewares)
+		trie.insertRoute(&Route{methods: record.methods, path: record.path, handler: record.handler, middlewares: record.middlewares})
 	}
 
 	for _, test := range tests {
@@ -457,3 +641,510 @@ func TestSearchError(t *testing.T) {
 		})
 	}
 }
+
+func TestSearchCatchAll(t *testing.T) {
+	type testCase struct {
+		name     string
+		path     string
+		expected http.Handler
+		captured bool
+		value    string
+	}
+
+	staticHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	indexHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	paramHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	trie := newTrie()
+	trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/static/*filepath", handler: staticHandler})
+	trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/static/index", handler: indexHandler})
+	trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/static/:id[^\\d+$]", handler: paramHandler})
+
+	tests := []testCase{
+		{name: "NestedRemainder", path: "/static/css/app.css", expected: staticHandler, captured: true, value: "css/app.css"},
+		{name: "TrailingRemainder", path: "/static/css/", expected: staticHandler, captured: true, value: "css/"},
+		{name: "EmptyRemainder", path: "/static", expected: staticHandler, captured: true, value: ""},
+		{name: "StaticPrecedence", path: "/static/index", expected: indexHandler},
+		{name: "ParameterPrecedence", path: "/static/12", expected: paramHandler},
+		{name: "BeneathParameter", path: "/static/12/more", expected: staticHandler, captured: true, value: "12/more"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := trie.search(http.MethodGet, test.path)
+			if err != nil {
+				t.Fatalf("expected a result but got error %v", err)
+			}
+
+			if reflect.ValueOf(actual.actions.handler) != reflect.ValueOf(test.expected) {
+				t.Errorf("expected handler %v but got %v", test.expected, actual.actions.handler)
+			}
+
+			if test.captured {
+				if len(actual.parameters) != 1 || actual.parameters[0].key != "filepath" || actual.parameters[0].value != test.value {
+					t.Errorf("expected filepath parameter %s but got %v", test.value, actual.parameters)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchBacktracking(t *testing.T) {
+	type testCase struct {
+		name     string
+		method   string
+		path     string
+		expected http.Handler
+		params   []*parameter
+		err      error
+	}
+
+	idHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	nameHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	idPostsHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	namePostHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	anyCommentsHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	trie := newTrie()
+	trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/users/:id[^\\d+$]", handler: idHandler})
+	trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/users/:name[^[a-z]+$]", handler: nameHandler})
+	trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/users/:id[^\\d+$]/posts", handler: idPostsHandler})
+	trie.insertRoute(&Route{methods: []string{http.MethodPost}, path: "/users/:name[^[a-z0-9]+$]/:post", handler: namePostHandler})
+	trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/users/:any/comments", handler: anyCommentsHandler})
+
+	tests := []testCase{
+		{
+			name:     "FirstSibling",
+			method:   http.MethodGet,
+			path:     "/users/12",
+			expected: idHandler,
+			params:   []*parameter{{key: "id", value: "12"}},
+		},
+		{
+			name:     "SecondSibling",
+			method:   http.MethodGet,
+			path:     "/users/alice",
+			expected: nameHandler,
+			params:   []*parameter{{key: "name", value: "alice"}},
+		},
+		{
+			name:     "NestedFirstSibling",
+			method:   http.MethodGet,
+			path:     "/users/12/posts",
+			expected: idPostsHandler,
+			params:   []*parameter{{key: "id", value: "12"}},
+		},
+		{
+			name:     "BacktrackToSibling",
+			method:   http.MethodPost,
+			path:     "/users/12/first",
+			expected: namePostHandler,
+			params:   []*parameter{{key: "name", value: "12"}, {key: "post", value: "first"}},
+		},
+		{
+			name:     "BacktrackToLastSibling",
+			method:   http.MethodGet,
+			path:     "/users/12/comments",
+			expected: anyCommentsHandler,
+			params:   []*parameter{{key: "any", value: "12"}},
+		},
+		{
+			name:   "MethodNotAllowedAcrossSiblings",
+			method: http.MethodDelete,
+			path:   "/users/12/first",
+			err:    ErrMethodNotAllowed,
+		},
+		{
+			name:   "NoSiblingMatch",
+			method: http.MethodGet,
+			path:   "/users/Alice",
+			err:    ErrNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Repeat the search to surface any nondeterminism.
+			for i := 0; i < 20; i++ {
+				actual, err := trie.search(test.method, test.path)
+
+				if test.err != nil {
+					if err != test.err {
+						t.Fatalf("expected error %v but got %v", test.err, err)
+					}
+					continue
+				}
+
+				if err != nil {
+					t.Fatalf("expected a result but got error %v", err)
+				}
+
+				if reflect.ValueOf(actual.actions.handler) != reflect.ValueOf(test.expected) {
+					t.Fatalf("expected handler %v but got %v", test.expected, actual.actions.handler)
+				}
+
+				if !reflect.DeepEqual(actual.parameters, test.params) {
+					t.Fatalf("expected parameters %v but got %v", test.params, actual.parameters)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchPrecedence(t *testing.T) {
+	type testCase struct {
+		name     string
+		path     string
+		expected string
+	}
+
+	routes := []string{
+		"/users/*rest",
+		"/users/:slug",
+		"/users/:id[^\\d+$]",
+		"/users/me",
+	}
+
+	tests := []testCase{
+		{name: "StaticOverRegex", path: "/users/me", expected: "/users/me"},
+		{name: "RegexOverParameter", path: "/users/42", expected: "/users/:id[^\\d+$]"},
+		{name: "ParameterOverCatchAll", path: "/users/alice", expected: "/users/:slug"},
+		{name: "CatchAll", path: "/users/alice/posts", expected: "/users/*rest"},
+		{name: "CatchAllEmpty", path: "/users", expected: "/users/*rest"},
+	}
+
+	// Every insertion order must yield the same precedence.
+	for _, order := range permutations(routes) {
+		trie := newTrie()
+		handlers := make(map[string]http.Handler)
+
+		for _, route := range order {
+			route := route
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(route))
+			})
+
+			handlers[route] = handler
+			trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: route, handler: handler})
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				actual, err := trie.search(http.MethodGet, test.path)
+				if err != nil {
+					t.Fatalf("expected a result but got error %v (insertion order %v)", err, order)
+				}
+
+				if reflect.ValueOf(actual.actions.handler) != reflect.ValueOf(handlers[test.expected]) {
+					t.Errorf("expected %s to match route %s (insertion order %v)", test.path, test.expected, order)
+				}
+			})
+		}
+	}
+}
+
+func TestSearchComposite(t *testing.T) {
+	type testCase struct {
+		name     string
+		path     string
+		expected http.Handler
+		params   []*parameter
+		err      error
+	}
+
+	fileHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	readmeHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	versionHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	archiveHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	anyHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	trie := newTrie()
+	trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/files/:name.:ext", handler: fileHandler})
+	trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/files/README.md", handler: readmeHandler})
+	trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/v:major[int].:minor[int]/status", handler: versionHandler})
+	trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/archive/:year[^\\d{4}$]-:month[^\\d{2}$]", handler: archiveHandler})
+	trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/archive/:any", handler: anyHandler})
+
+	tests := []testCase{
+		{
+			name:     "NameAndExtension",
+			path:     "/files/report.pdf",
+			expected: fileHandler,
+			params:   []*parameter{{key: "name", value: "report"}, {key: "ext", value: "pdf"}},
+		},
+		{
+			name:     "LazyLeadingParameter",
+			path:     "/files/archive.tar.gz",
+			expected: fileHandler,
+			params:   []*parameter{{key: "name", value: "archive"}, {key: "ext", value: "tar.gz"}},
+		},
+		{name: "StaticPrecedence", path: "/files/README.md", expected: readmeHandler},
+		{
+			name:     "LeadingLiteral",
+			path:     "/v2.10/status",
+			expected: versionHandler,
+			params:   []*parameter{{key: "major", value: "2"}, {key: "minor", value: "10"}},
+		},
+		{name: "ConstrainedPieceMismatch", path: "/v2.x/status", err: ErrNotFound},
+		{
+			name:     "Hyphenated",
+			path:     "/archive/2024-02",
+			expected: archiveHandler,
+			params:   []*parameter{{key: "year", value: "2024"}, {key: "month", value: "02"}},
+		},
+		{
+			name:     "FallbackToParameter",
+			path:     "/archive/2024-2",
+			expected: anyHandler,
+			params:   []*parameter{{key: "any", value: "2024-2"}},
+		},
+		{name: "MissingLiteral", path: "/files/report", err: ErrNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := trie.search(http.MethodGet, test.path)
+
+			if test.err != nil {
+				if err != test.err {
+					t.Fatalf("expected error %v but got %v", test.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected a result but got error %v", err)
+			}
+
+			if reflect.ValueOf(actual.actions.handler) != reflect.ValueOf(test.expected) {
+				t.Errorf("expected handler %v but got %v", test.expected, actual.actions.handler)
+			}
+
+			if !reflect.DeepEqual(actual.parameters, test.params) {
+				t.Errorf("expected parameters %v but got %v", test.params, actual.parameters)
+			}
+		})
+	}
+}
+
+func TestSearchOptional(t *testing.T) {
+	type testCase struct {
+		name     string
+		method   string
+		path     string
+		expected http.Handler
+		params   []*parameter
+		allowed  []string
+		err      error
+	}
+
+	reportsHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	docsHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	listHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	createHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	trie := newTrie()
+	trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/reports/:year[^\\d{4}$]?/:month[^\\d{2}$]?", handler: reportsHandler})
+	trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/docs/:lang[^[a-z]{2}$]?/:page", handler: docsHandler})
+	trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/items", handler: listHandler})
+	trie.insertRoute(&Route{methods: []string{http.MethodPost}, path: "/items/:id?", handler: createHandler})
+
+	tests := []testCase{
+		{name: "AllAbsent", method: http.MethodGet, path: "/reports", expected: reportsHandler},
+		{
+			name:     "TrailingAbsent",
+			method:   http.MethodGet,
+			path:     "/reports/2024",
+			expected: reportsHandler,
+			params:   []*parameter{{key: "year", value: "2024"}},
+		},
+		{
+			name:     "AllPresent",
+			method:   http.MethodGet,
+			path:     "/reports/2024/02",
+			expected: reportsHandler,
+			params:   []*parameter{{key: "year", value: "2024"}, {key: "month", value: "02"}},
+		},
+		{
+			name:     "LeadingAbsent",
+			method:   http.MethodGet,
+			path:     "/reports/02",
+			expected: reportsHandler,
+			params:   []*parameter{{key: "month", value: "02"}},
+		},
+		{
+			name:     "InnerPresent",
+			method:   http.MethodGet,
+			path:     "/docs/en/intro",
+			expected: docsHandler,
+			params:   []*parameter{{key: "lang", value: "en"}, {key: "page", value: "intro"}},
+		},
+		{
+			name:     "InnerAbsent",
+			method:   http.MethodGet,
+			path:     "/docs/intro",
+			expected: docsHandler,
+			params:   []*parameter{{key: "page", value: "intro"}},
+		},
+		{
+			name:     "InnerAbsentBacktracking",
+			method:   http.MethodGet,
+			path:     "/docs/en",
+			expected: docsHandler,
+			params:   []*parameter{{key: "page", value: "en"}},
+		},
+		{name: "RequiredAbsent", method: http.MethodGet, path: "/docs", err: ErrNotFound},
+		{name: "AbsentOtherMethod", method: http.MethodPost, path: "/items", expected: createHandler},
+		{name: "AbsentAllowed", method: http.MethodDelete, path: "/items", allowed: []string{http.MethodGet, http.MethodPost}, err: ErrMethodNotAllowed},
+		{name: "TooManySegments", method: http.MethodGet, path: "/reports/2024/02/01", err: ErrNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := trie.search(test.method, test.path)
+
+			if test.err != nil {
+				if err != test.err {
+					t.Fatalf("expected error %v but got %v", test.err, err)
+				}
+
+				if test.allowed != nil && !reflect.DeepEqual(actual.allowed, test.allowed) {
+					t.Errorf("expected allowed methods %v but got %v", test.allowed, actual.allowed)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected a result but got error %v", err)
+			}
+
+			if reflect.ValueOf(actual.actions.handler) != reflect.ValueOf(test.expected) {
+				t.Errorf("expected handler %v but got %v", test.expected, actual.actions.handler)
+			}
+
+			if !reflect.DeepEqual(actual.parameters, test.params) {
+				t.Errorf("expected parameters %v but got %v", test.params, actual.parameters)
+			}
+		})
+	}
+}
+
+func TestDeriveNodeKind(t *testing.T) {
+	type testCase struct {
+		name     string
+		input    string
+		expected nodeKind
+	}
+
+	tests := []testCase{
+		{name: "Static", input: "users", expected: kindStatic},
+		{name: "Regex", input: ":id[^\\d+$]", expected: kindRegex},
+		{name: "RegexWithDelimiter", input: ":time[^\\d{2}:\\d{2}$]", expected: kindRegex},
+		{name: "Composite", input: ":name.:ext", expected: kindComposite},
+		{name: "LeadingLiteralComposite", input: "v:major", expected: kindComposite},
+		{name: "Parameter", input: ":id", expected: kindParameter},
+		{name: "CatchAll", input: "*rest", expected: kindCatchAll},
+		{name: "OptionalParameter", input: ":id?", expected: kindParameter},
+		{name: "OptionalRegex", input: ":id[int]?", expected: kindRegex},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := deriveNodeKind(test.input); actual != test.expected {
+				t.Errorf("expected %v but got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func permutations(s []string) [][]string {
+	if len(s) <= 1 {
+		return [][]string{append([]string(nil), s...)}
+	}
+
+	var ret [][]string
+	for i := range s {
+		rest := append(append([]string(nil), s[:i]...), s[i+1:]...)
+		for _, p := range permutations(rest) {
+			ret = append(ret, append([]string{s[i]}, p...))
+		}
+	}
+
+	return ret
+}
+
+func TestSearchFold(t *testing.T) {
+	type testCase struct {
+		name     string
+		path     string
+		expected http.Handler
+		matched  string
+		params   []*parameter
+	}
+
+	usersHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	upperHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	userHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	barHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	bazHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	accountHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	adminHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	trie := newTrie()
+	trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/users", handler: usersHandler})
+	trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/USERS", handler: upperHandler})
+	trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/api/Users/:id/Profile", handler: userHandler})
+	trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/Foo/bar", handler: barHandler})
+	trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/foo/baz", handler: bazHandler})
+	trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/accounts/:id", handler: accountHandler})
+	trie.insertRoute(&Route{methods: []string{http.MethodGet}, path: "/Accounts/Admin", handler: adminHandler})
+
+	tests := []testCase{
+		{name: "ExactMatch", path: "/users", expected: usersHandler, matched: "/users"},
+		{name: "ExactMatchPrecedence", path: "/USERS", expected: upperHandler, matched: "/USERS"},
+		{name: "FoldedMatch", path: "/Users/", expected: usersHandler, matched: "/users/"},
+		{
+			name:     "FoldedMatchRetainsParameterCase",
+			path:     "/API/users/AbC/profile",
+			expected: userHandler,
+			matched:  "/api/Users/AbC/Profile",
+			params:   []*parameter{{key: "id", value: "AbC"}},
+		},
+		{name: "FirstCaseVariant", path: "/FOO/BAR", expected: barHandler, matched: "/Foo/bar"},
+		{name: "LaterCaseVariant", path: "/FOO/baz", expected: bazHandler, matched: "/foo/baz"},
+		{name: "LaterCaseVariantFolded", path: "/fOO/BAZ", expected: bazHandler, matched: "/foo/baz"},
+		{name: "MostSpecificCaseVariant", path: "/ACCOUNTS/ADMIN", expected: adminHandler, matched: "/Accounts/Admin"},
+		{
+			name:     "ParameterCaseVariant",
+			path:     "/ACCOUNTS/42",
+			expected: accountHandler,
+			matched:  "/accounts/42",
+			params:   []*parameter{{key: "id", value: "42"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := trie.searchFold(http.MethodGet, test.path)
+			if err != nil {
+				t.Fatalf("expected a result but got error %v", err)
+			}
+
+			if reflect.ValueOf(actual.actions.handler) != reflect.ValueOf(test.expected) {
+				t.Errorf("expected handler %v but got %v", test.expected, actual.actions.handler)
+			}
+
+			if actual.path != test.matched {
+				t.Errorf("expected matched path %s but got %s", test.matched, actual.path)
+			}
+
+			if !reflect.DeepEqual(actual.parameters, test.params) {
+				t.Errorf("expected parameters %v but got %v", test.params, actual.parameters)
+			}
+		})
+	}
+
+	if _, err := trie.search(http.MethodGet, "/Users"); err != ErrNotFound {
+		t.Errorf("expected a case-sensitive search to fail with %v but got %v", ErrNotFound, err)
+	}
+}
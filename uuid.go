@@ -0,0 +1,33 @@
+package turnpike
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// UUID represents a universally unique identifier per RFC 9562.
+type UUID [16]byte
+
+// ParseUUID parses a UUID in its canonical textual form, e.g. 123e4567-e89b-12d3-a456-426614174000.
+// Hexadecimal digits may be upper or lower case.
+func ParseUUID(s string) (UUID, error) {
+	var uuid UUID
+
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return uuid, fmt.Errorf("invalid UUID %q", s)
+	}
+
+	digits := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:36]
+	if _, err := hex.Decode(uuid[:], []byte(digits)); err != nil {
+		return uuid, fmt.Errorf("invalid UUID %q: %v", s, err)
+	}
+
+	return uuid, nil
+}
+
+// String returns the canonical textual form of the UUID, in lower case.
+func (u UUID) String() string {
+	s := hex.EncodeToString(u[:])
+
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:32]
+}
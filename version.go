@@ -0,0 +1,278 @@
+package turnpike
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// VersionPathPrefix prefixes the name of an API version in a request path, e.g. /v2/users.
+const VersionPathPrefix = "v"
+
+// vendorVersion matches a vendor media subtype that embeds an API version, e.g. vnd.acme.v2+json.
+var vendorVersion = regexp.MustCompile(`^vnd\.[^+]*\bv([^.+]+)(\+.*)?$`)
+
+// Version represents an API version declared on a Router. See Router.Version.
+type Version struct {
+	name string
+	// order holds the position of the Version among the Router's Versions, in order of declaration.
+	order       int
+	deprecation time.Time
+	sunset      time.Time
+}
+
+// Name returns the name of the Version, e.g. 2.
+func (v *Version) Name() string {
+	return v.name
+}
+
+// Deprecate marks the Version as deprecated as of the given time. Responses to requests for the Version carry
+// a Deprecation header per RFC 9745, e.g. Deprecation: @1688169599.
+// Versions must be configured before the Router serves requests.
+func (v *Version) Deprecate(at time.Time) *Version {
+	v.deprecation = at
+
+	return v
+}
+
+// Sunset sets the time at which the Version is expected to become unavailable. Responses to requests for the
+// Version carry a Sunset header per RFC 8594, e.g. Sunset: Sat, 31 Dec 2050 23:59:59 GMT.
+// Versions must be configured before the Router serves requests.
+func (v *Version) Sunset(at time.Time) *Version {
+	v.sunset = at
+
+	return v
+}
+
+// setHeaders sets the Deprecation and Sunset headers of the Version, if extant, on the given header.
+func (v *Version) setHeaders(h http.Header) {
+	if !v.deprecation.IsZero() {
+		h.Set("Deprecation", fmt.Sprintf("@%d", v.deprecation.Unix()))
+	}
+
+	if !v.sunset.IsZero() {
+		h.Set("Sunset", v.sunset.UTC().Format(http.TimeFormat))
+	}
+}
+
+// Version declares the API version named `name`, creates a Group of Route records registered against it and passes
+// the Group to `fn`. Versions are ordered by declaration and must be declared in ascending order; declaring
+// a version again passes a new Group for the same version. A leading VersionPathPrefix in `name` is disregarded,
+// e.g. v2 and 2 name the same version.
+//
+// The API version of a request is resolved, in order, from a path prefix if VersionPrefix is enabled, from the
+// VersionHeader if specified, and from the Accept header if VersionMediaType is enabled. A request whose version
+// is not resolved is served the latest declared version; a request for an undeclared version is served by the
+// NotFoundHandler.
+//
+// A request is served by the Route record registered against the version it requests or, failing that, against
+// the nearest lower version, such that a Route record need not be registered again for versions in which it is
+// unchanged. Route records registered without a version serve requests for which no versioned Route record exists.
+// The resolved version is available to handlers via APIVersion.
+func (r *Router) Version(name string, fn func(g *Group)) *Version {
+	name = normalizeVersion(name)
+
+	r.mu.Lock()
+	v := r.version(name)
+	if v == nil {
+		v = &Version{name: name, order: len(r.versions)}
+		r.versions = append(r.versions, v)
+	}
+	r.mu.Unlock()
+
+	g := newGroup(r, nil, "")
+	g.version = v
+
+	fn(g)
+
+	return v
+}
+
+// version returns the Router's Version with the given normalized name, if extant.
+func (r *Router) version(name string) *Version {
+	for _, v := range r.versions {
+		if v.name == name {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// normalizeVersion strips any leading VersionPathPrefix from the given version name, disregarding case.
+// e.g. v2 → 2
+func normalizeVersion(name string) string {
+	if len(name) > len(VersionPathPrefix) && strings.EqualFold(name[:len(VersionPathPrefix)], VersionPathPrefix) {
+		return name[len(VersionPathPrefix):]
+	}
+
+	return name
+}
+
+// resolveVersion resolves the API version of the given request, whose clean path is `path`, per the Router's
+// versioning configuration. If the version is resolved from a path prefix, resolveVersion returns the prefix too.
+// resolveVersion returns ErrNotFound if the request names an undeclared version, and a nil Version if the Router
+// declares none.
+func (r *Router) resolveVersion(w http.ResponseWriter, req *http.Request, path string) (*Version, string, error) {
+	if len(r.versions) == 0 {
+		return nil, "", nil
+	}
+
+	if r.VersionPrefix {
+		if segments := expandPath(path); len(segments) > 0 && strings.HasPrefix(segments[0], VersionPathPrefix) {
+			if v := r.version(normalizeVersion(segments[0])); v != nil {
+				return v, PathDelimiter + segments[0], nil
+			}
+		}
+	}
+
+	if r.VersionHeader != "" {
+		addVary(w.Header(), r.VersionHeader)
+
+		if name := req.Header.Get(r.VersionHeader); name != "" {
+			return r.declaredVersion(name)
+		}
+	}
+
+	if r.VersionMediaType {
+		addVary(w.Header(), "Accept")
+
+		if name, ok := mediaTypeVersion(req.Header.Values("Accept")); ok {
+			return r.declaredVersion(name)
+		}
+	}
+
+	return r.versions[len(r.versions)-1], "", nil
+}
+
+// declaredVersion returns the Router's Version with the given name, or ErrNotFound if the Router declares none.
+func (r *Router) declaredVersion(name string) (*Version, string, error) {
+	if v := r.version(normalizeVersion(strings.TrimSpace(name))); v != nil {
+		return v, "", nil
+	}
+
+	return nil, "", ErrNotFound
+}
+
+// mediaTypeVersion resolves an API version from the first of the given Accept header values' media ranges that
+// names one, either by a version parameter or by a vendor subtype.
+// e.g. application/json; version=2 → 2
+// e.g. application/vnd.acme.v2+json → 2
+func mediaTypeVersion(values []string) (string, bool) {
+	for _, value := range values {
+		for _, s := range strings.Split(value, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(s))
+			if err != nil {
+				continue
+			}
+
+			if name, ok := params["version"]; ok {
+				return name, true
+			}
+
+			if _, subtype, ok := strings.Cut(mediaType, "/"); ok {
+				if match := vendorVersion.FindStringSubmatch(subtype); match != nil {
+					return match[1], true
+				}
+			}
+		}
+	}
+
+	return "", false
+}
+
+// withUnversionedRanges returns the given media ranges, each followed by its unversioned form if it names an API
+// version, for content negotiation: that without the version parameter, or whose vendor subtype is replaced by its
+// structured syntax suffix. Each retains its quality.
+// e.g. application/json; version=2 → application/json; version=2, application/json
+// e.g. application/vnd.acme.v2+json → application/vnd.acme.v2+json, application/json
+func withUnversionedRanges(ranges []mediaRange) []mediaRange {
+	var ret []mediaRange
+
+	for _, r := range ranges {
+		ret = append(ret, r)
+
+		unversioned := r
+		versioned := false
+
+		if _, ok := r.params["version"]; ok {
+			unversioned.params = make(map[string]string, len(r.params)-1)
+			for key, value := range r.params {
+				if key != "version" {
+					unversioned.params[key] = value
+				}
+			}
+
+			versioned = true
+		}
+
+		if match := vendorVersion.FindStringSubmatch(r.subtype); match != nil && match[2] != "" {
+			unversioned.subtype = strings.TrimPrefix(match[2], "+")
+			versioned = true
+		}
+
+		if versioned {
+			ret = append(ret, unversioned)
+		}
+	}
+
+	return ret
+}
+
+// selectVersion selects among the given actions those to serve a request for the given Version with: the actions
+// registered against the nearest Version no greater than it, followed by those registered without a Version.
+func selectVersion(actions []*action, v *Version) []*action {
+	var nearest *Version
+
+	for _, a := range actions {
+		if a.version != nil && a.version.order <= v.order && (nearest == nil || a.version.order > nearest.order) {
+			nearest = a.version
+		}
+	}
+
+	var selected []*action
+
+	for _, a := range actions {
+		if a.version == nearest && nearest != nil {
+			selected = append(selected, a)
+		}
+	}
+
+	for _, a := range actions {
+		if a.version == nil {
+			selected = append(selected, a)
+		}
+	}
+
+	return selected
+}
+
+// withPrefix returns the given path prefixed with the given version prefix, if any.
+// e.g. (/v2, /users) → /v2/users
+// e.g. (/v2, /)      → /v2
+func withPrefix(prefix string, path string) string {
+	if prefix == "" {
+		return path
+	}
+
+	if path == PathRoot {
+		return prefix
+	}
+
+	return prefix + path
+}
+
+// APIVersion retrieves from context the name of the API version resolved for the request, if the Router declares
+// any. See Router.Version.
+func APIVersion(ctx context.Context) string {
+	v, _ := ctx.Value(versionKey).(*Version)
+	if v == nil {
+		return ""
+	}
+
+	return v.name
+}
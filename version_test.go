@@ -0,0 +1,292 @@
+package turnpike
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNormalizeVersion(t *testing.T) {
+	for input, expected := range map[string]string{"v2": "2", "V2": "2", "2": "2", "v": "v", "2024-01-01": "2024-01-01"} {
+		if actual := normalizeVersion(input); actual != expected {
+			t.Errorf("expected %s for %s but got %s", expected, input, actual)
+		}
+	}
+}
+
+func TestMediaTypeVersion(t *testing.T) {
+	type testCase struct {
+		name     string
+		input    string
+		expected string
+		ok       bool
+	}
+
+	tests := []testCase{
+		{name: "Parameter", input: "application/json; version=2", expected: "2", ok: true},
+		{name: "VendorSubtype", input: "application/vnd.acme.v2+json", expected: "2", ok: true},
+		{name: "VendorSubtypeWithoutSuffix", input: "application/vnd.acme.v3", expected: "3", ok: true},
+		{name: "FirstNamingRange", input: "text/html, application/vnd.acme.v2+json;q=0.9", expected: "2", ok: true},
+		{name: "Unversioned", input: "application/json, application/vnd.acme+json"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, ok := mediaTypeVersion([]string{test.input})
+			if actual != test.expected || ok != test.ok {
+				t.Errorf("expected (%s, %v) but got (%s, %v)", test.expected, test.ok, actual, ok)
+			}
+		})
+	}
+}
+
+func TestVersioning(t *testing.T) {
+	type testCase struct {
+		name    string
+		target  string
+		header  http.Header
+		code    int
+		body    string
+		headers map[string]string
+	}
+
+	respond := func(s string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "%s@%s", s, APIVersion(r.Context()))
+		})
+	}
+
+	deprecation := time.Date(2023, time.June, 30, 23, 59, 59, 0, time.UTC)
+	sunset := time.Date(2050, time.December, 31, 23, 59, 59, 0, time.UTC)
+
+	r := NewRouter()
+	r.VersionPrefix = true
+	r.VersionHeader = "X-API-Version"
+	r.VersionMediaType = true
+
+	r.Version("v1", func(g *Group) {
+		g.WithMethods(http.MethodGet).Handler("/users/:id", respond("users.v1")).MustRegister()
+		g.WithMethods(http.MethodGet).Handler("/orders", respond("orders.v1")).MustRegister()
+	}).Deprecate(deprecation).Sunset(sunset)
+
+	r.Version("v2", func(g *Group) {
+		g.WithMethods(http.MethodGet).Handler("/users/:id", respond("users.v2")).MustRegister()
+		g.WithMethods(http.MethodGet).Handler("/invoices", respond("invoices.v2")).MustRegister()
+	})
+
+	r.Version("v3", func(g *Group) {})
+
+	r.WithMethods(http.MethodGet).Handler("/health", respond("health")).MustRegister()
+
+	deprecated := map[string]string{"Deprecation": "@1688169599", "Sunset": "Sat, 31 Dec 2050 23:59:59 GMT"}
+
+	tests := []testCase{
+		{name: "Prefix", target: "/v2/users/42", code: http.StatusOK, body: "users.v2@2"},
+		{name: "PrefixDeprecated", target: "/v1/users/42", code: http.StatusOK, body: "users.v1@1", headers: deprecated},
+		{name: "PrefixPrecedence", target: "/v1/users/42", header: http.Header{"X-Api-Version": {"2"}}, code: http.StatusOK, body: "users.v1@1"},
+		{name: "NearestLowerVersion", target: "/v3/users/42", code: http.StatusOK, body: "users.v2@3"},
+		{name: "UnchangedRoute", target: "/v2/orders", code: http.StatusOK, body: "orders.v1@2"},
+		{name: "NoLowerVersion", target: "/v1/invoices", code: http.StatusNotFound},
+		{name: "UndeclaredPrefix", target: "/v4/users/42", code: http.StatusNotFound},
+		{name: "Header", target: "/users/42", header: http.Header{"X-Api-Version": {"1"}}, code: http.StatusOK, body: "users.v1@1", headers: deprecated},
+		{name: "UndeclaredHeader", target: "/users/42", header: http.Header{"X-Api-Version": {"4"}}, code: http.StatusNotFound},
+		{name: "MediaTypeParameter", target: "/users/42", header: http.Header{"Accept": {"application/json; version=1"}}, code: http.StatusOK, body: "users.v1@1"},
+		{name: "VendorMediaType", target: "/users/42", header: http.Header{"Accept": {"application/vnd.acme.v2+json"}}, code: http.StatusOK, body: "users.v2@2"},
+		{name: "HeaderPrecedence", target: "/users/42", header: http.Header{"X-Api-Version": {"1"}, "Accept": {"application/vnd.acme.v2+json"}}, code: http.StatusOK, body: "users.v1@1"},
+		{name: "LatestByDefault", target: "/users/42", code: http.StatusOK, body: "users.v2@3", headers: map[string]string{"Vary": "X-API-Version"}},
+		{name: "Unversioned", target: "/v1/health", code: http.StatusOK, body: "health@1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.target, nil)
+			if test.header != nil {
+				req.Header = test.header
+			}
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != test.code {
+				t.Errorf("expected code %d but got %d", test.code, rec.Code)
+			}
+
+			if body, _ := ioutil.ReadAll(rec.Body); test.body != "" && string(body) != test.body {
+				t.Errorf("expected body %s but got %s", test.body, body)
+			}
+
+			for key, expected := range test.headers {
+				if actual := rec.Header().Get(key); actual != expected {
+					t.Errorf("expected %s header %s but got %s", key, expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestVersionedNegotiation(t *testing.T) {
+	type testCase struct {
+		name      string
+		accept    string
+		code      int
+		body      string
+		mediaType string
+	}
+
+	respond := func(s string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "%s@%s:%s", s, APIVersion(r.Context()), NegotiatedMediaType(r.Context()))
+		})
+	}
+
+	r := NewRouter()
+	r.VersionHeader = "X-API-Version"
+	r.VersionMediaType = true
+
+	r.Version("v1", func(g *Group) {
+		g.WithMethods(http.MethodGet).Produces("application/json").Handler("/users", respond("users.v1")).MustRegister()
+		g.WithMethods(http.MethodGet).Produces("text/csv").Handler("/users", respond("users.v1")).MustRegister()
+	})
+
+	r.Version("v2", func(g *Group) {
+		g.WithMethods(http.MethodGet).Produces("application/json").Handler("/users", respond("users.v2")).MustRegister()
+		g.WithMethods(http.MethodGet).Produces("application/vnd.acme.v2+xml").Handler("/users", respond("users.v2")).MustRegister()
+	})
+
+	tests := []testCase{
+		{name: "VendorMediaType", accept: "application/vnd.acme.v1+json", code: http.StatusOK, body: "users.v1@1:application/json"},
+		{name: "MediaTypeParameter", accept: "application/json; version=1", code: http.StatusOK, body: "users.v1@1:application/json"},
+		{name: "MediaTypeParameterAlternative", accept: "text/csv; version=1", code: http.StatusOK, body: "users.v1@1:text/csv"},
+		{name: "ProducedVendorMediaType", accept: "application/vnd.acme.v2+xml", code: http.StatusOK, body: "users.v2@2:application/vnd.acme.v2+xml"},
+		{name: "QualityRetained", accept: "application/vnd.acme.v2+json;q=0.5, application/vnd.acme.v2+xml", code: http.StatusOK, body: "users.v2@2:application/vnd.acme.v2+xml"},
+		{name: "Unversioned", accept: "application/json", code: http.StatusOK, body: "users.v2@2:application/json"},
+		{name: "NotAcceptable", accept: "application/vnd.acme.v1+xml", code: http.StatusNotAcceptable},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			req.Header.Set("Accept", test.accept)
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != test.code {
+				t.Errorf("expected code %d but got %d", test.code, rec.Code)
+			}
+
+			if body, _ := ioutil.ReadAll(rec.Body); test.body != "" && string(body) != test.body {
+				t.Errorf("expected body %s but got %s", test.body, body)
+			}
+
+			// Both version resolution and negotiation vary by the Accept header, which is listed once.
+			if vary := rec.Header().Values("Vary"); !reflect.DeepEqual(vary, []string{"X-API-Version", "Accept"}) {
+				t.Errorf("expected Vary headers [X-API-Version Accept] but got %v", vary)
+			}
+		})
+	}
+}
+
+func TestVersionedParameterRename(t *testing.T) {
+	respond := func(s string, key string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "%s:%s", s, GetParam(r.Context(), key))
+		})
+	}
+
+	r := NewRouter()
+	r.VersionPrefix = true
+
+	r.Version("1", func(g *Group) {
+		g.WithMethods(http.MethodGet).Handler("/a/:x", respond("a.v1", "x")).MustRegister()
+	})
+
+	r.Version("2", func(g *Group) {
+		if err := g.WithMethods(http.MethodGet).Handler("/a/:y", respond("a.v2", "y")).Register(); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	})
+
+	// The same version remains ambiguous.
+	r.Version("2", func(g *Group) {
+		if err := g.WithMethods(http.MethodGet).Handler("/a/:z", respond("a.v2", "z")).Register(); !errors.Is(err, ErrAmbiguousRoute) {
+			t.Errorf("expected error %v but got %v", ErrAmbiguousRoute, err)
+		}
+	})
+
+	for target, expected := range map[string]string{"/v1/a/42": "a.v1:42", "/v2/a/42": "a.v2:42"} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+		if body := rec.Body.String(); rec.Code != http.StatusOK || body != expected {
+			t.Errorf("expected %s for %s but got %d %s", expected, target, rec.Code, body)
+		}
+	}
+}
+
+func TestVersionPathPolicy(t *testing.T) {
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	r := NewRouter()
+	r.VersionPrefix = true
+	r.PathPolicy = PathRedirect
+
+	r.Version("1", func(g *Group) {
+		g.WithMethods(http.MethodGet).Handler("/users", testHandler).MustRegister()
+		g.WithMethods(http.MethodGet).Handler("/", testHandler).MustRegister()
+	})
+
+	for target, location := range map[string]string{"/v1/users/": "/v1/users", "/v1//users": "/v1/users", "/v1/": "/v1"} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+		if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != location {
+			t.Errorf("expected %s to redirect to %s but got %d %s", target, location, rec.Code, rec.Header().Get("Location"))
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected code %d but got %d", http.StatusOK, rec.Code)
+	}
+}
+
+func TestVersionRegistration(t *testing.T) {
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	r := NewRouter()
+
+	v1 := r.Version("1", func(g *Group) {
+		g.WithMethods(http.MethodGet).Handler("/users", testHandler).MustRegister()
+
+		if err := g.WithMethods(http.MethodGet).Handler("/users", testHandler).Register(); !errors.Is(err, ErrDuplicateRoute) {
+			t.Errorf("expected error %v but got %v", ErrDuplicateRoute, err)
+		}
+	})
+
+	r.Version("2", func(g *Group) {
+		g.Group("/admin", func(g *Group) {
+			g.WithMethods(http.MethodGet).Handler("/users", testHandler).MustRegister()
+		})
+
+		if err := g.WithMethods(http.MethodGet).Handler("/users", testHandler).Register(); err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+	})
+
+	if v := r.Version("v1", func(g *Group) {}); v != v1 {
+		t.Error("expected redeclaring a version to yield the same Version")
+	}
+
+	routes := r.Routes()
+	if len(routes) != 3 || routes[0].Pattern != "/admin/users" || routes[0].Version != "2" {
+		t.Errorf("unexpected routes %v", routes)
+	}
+}